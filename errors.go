@@ -0,0 +1,126 @@
+package mrpkg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// StatusError is returned from loadc generated api methods while the
+// status code of response is not accepted
+type StatusError struct {
+	Caller     string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("response status code %d for %s", e.StatusCode, strconv.Quote(e.Caller))
+	}
+	return fmt.Sprintf("response status code %d for %s with body: \n\n%s\n\n", e.StatusCode, strconv.Quote(e.Caller), e.Body)
+}
+
+// ErrNoRows is returned (wrapped) from loadc generated sqlx methods when no
+// row is found or affected, it is the same value as sql.ErrNoRows
+var ErrNoRows = sql.ErrNoRows
+
+// ErrStaleVersion is returned (wrapped) from loadc generated sqlx methods with
+// VERSIONED feature while a statement affects no rows, which usually means
+// the version column has been changed by others, ErrStaleVersion is ErrNoRows
+// as well for errors.Is
+var ErrStaleVersion error = staleVersionError{}
+
+type staleVersionError struct{}
+
+func (staleVersionError) Error() string {
+	return "stale version: no rows affected"
+}
+
+func (staleVersionError) Is(target error) bool {
+	return target == ErrNoRows
+}
+
+// CheckVersion checks that result is affected by exactly one row, returns
+// ErrStaleVersion if no rows are affected
+func CheckVersion(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	switch n {
+	case 1:
+		return nil
+	case 0:
+		return ErrStaleVersion
+	default:
+		return fmt.Errorf("versioned statement expects exactly 1 row affected, got %d", n)
+	}
+}
+
+// ErrTimeout is returned (wrapped) from loadc generated sqlx methods with
+// timeout while the deadline is exceeded, the error returned by driver is
+// still available with errors.Is and errors.As
+var ErrTimeout = errors.New("timeout")
+
+type timeoutError struct {
+	err error
+}
+
+func (e *timeoutError) Error() string {
+	return "timeout: " + e.err.Error()
+}
+
+func (e *timeoutError) Unwrap() error {
+	return e.err
+}
+
+func (e *timeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// CheckTimeout returns err wrapped as ErrTimeout if ctx has exceeded its
+// deadline, drivers may report cancelled statements with their own errors
+// (or sql.ErrTxDone on commit), so the deadline of ctx is checked instead
+func CheckTimeout(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &timeoutError{err: err}
+	}
+	return err
+}
+
+// Phases of QueryError, which tell the step of sqlx method where error occurs
+const (
+	PhaseTemplate = "template"
+	PhaseBegin    = "begin"
+	PhasePrepare  = "prepare"
+	PhaseExec     = "exec"
+	PhaseResult   = "result"
+	PhaseScan     = "scan"
+	PhaseCommit   = "commit"
+)
+
+// QueryError is returned from loadc generated sqlx methods, Query and Args
+// are kept out of Error message so that callers can decide how to log them,
+// sensitive values in Args are redacted as in logs, Unwrap returns Err,
+// errors.Is(err, sql.ErrNoRows) works as before
+type QueryError struct {
+	Caller string
+	Phase  string
+	Query  string
+	Args   any
+	Err    error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("error in %s phase of %s: %v", e.Phase, strconv.Quote(e.Caller), e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,73 @@
+package mrpkg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	if err := CheckVersion(fakeResult{affected: 1}); err != nil {
+		t.Errorf("CheckVersion: expect nil with 1 row affected, got %v", err)
+	}
+
+	err := fmt.Errorf("wrapped: %w", CheckVersion(fakeResult{affected: 0}))
+	if !errors.Is(err, ErrStaleVersion) {
+		t.Errorf("CheckVersion: expect ErrStaleVersion with 0 row affected, got %v", err)
+	}
+
+	if !errors.Is(err, ErrNoRows) || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("CheckVersion: expect ErrStaleVersion to be ErrNoRows, got %v", err)
+	}
+
+	err = CheckVersion(fakeResult{affected: 2})
+	if err == nil || errors.Is(err, ErrStaleVersion) {
+		t.Errorf("CheckVersion: expect non-stale error with 2 rows affected, got %v", err)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	var err error = &QueryError{
+		Caller: "GetUser",
+		Phase:  PhaseExec,
+		Query:  "SELECT * FROM user WHERE id = ?",
+		Args:   []any{1},
+		Err:    sql.ErrNoRows,
+	}
+
+	err = fmt.Errorf("wrapped: %w", err)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("QueryError: expect to be sql.ErrNoRows, got %v", err)
+	}
+
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) || queryErr.Phase != PhaseExec || queryErr.Caller != "GetUser" {
+		t.Errorf("QueryError: expect errors.As to find QueryError, got %v", err)
+	}
+
+	if expect := `error in exec phase of "GetUser": ` + sql.ErrNoRows.Error(); queryErr.Error() != expect {
+		t.Errorf("QueryError.Error: expect=%s; got=%s", expect, queryErr.Error())
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	errExec := errors.New("exec")
+	if err := CheckTimeout(context.Background(), errExec); err != errExec {
+		t.Errorf("CheckTimeout: expect err as is without deadline, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+
+	if err := CheckTimeout(ctx, nil); err != nil {
+		t.Errorf("CheckTimeout: expect nil, got %v", err)
+	}
+
+	err := CheckTimeout(ctx, sql.ErrTxDone)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("CheckTimeout: expect ErrTimeout wrapping sql.ErrTxDone, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package mrpkg
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"path"
+	"reflect"
+	"sync"
+)
+
+const (
+	FormTag = "form"
+	FileTag = "file"
+)
+
+// FormField is a named value of form request body, Value could be:
+//
+//  1. an io.Reader, which is sent as a file part
+//  2. a struct (or pointer to struct), whose fields tagged with `form:"name"`
+//     are sent as values and fields tagged with `file:"name"` are sent as files
+//  3. a url.Values or map with string key, whose entries are sent as values
+//  4. a slice, whose elements are sent as multiple values with the same name
+//  5. any other value, which is formatted by fmt.Sprint
+type FormField struct {
+	Name  string
+	Value any
+}
+
+type formPart struct {
+	name     string
+	value    string
+	file     io.Reader
+	filename string
+}
+
+func expandFormField(dst []formPart, name string, value any) []formPart {
+	if value == nil {
+		return dst
+	}
+
+	if _, notAnArg := value.(NotAnArg); notAnArg {
+		return dst
+	}
+
+	if reader, ok := value.(io.Reader); ok {
+		return append(dst, formPart{name: name, file: reader, filename: filename(name, reader)})
+	}
+
+	if stringer, ok := value.(fmt.Stringer); ok {
+		return append(dst, formPart{name: name, value: stringer.String()})
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return dst
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if tag, ok := field.Tag.Lookup(FormTag); ok && tag != "-" {
+				dst = expandFormField(dst, tag, rv.Field(i).Interface())
+			} else if tag, ok = field.Tag.Lookup(FileTag); ok && tag != "-" {
+				if reader, isReader := rv.Field(i).Interface().(io.Reader); isReader && reader != nil {
+					dst = append(dst, formPart{name: tag, file: reader, filename: filename(tag, reader)})
+				}
+			}
+		}
+		return dst
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			dst = expandFormField(dst, iter.Key().String(), iter.Value().Interface())
+		}
+		return dst
+	case reflect.Slice, reflect.Array:
+		if rv.Type() == byteType {
+			return append(dst, formPart{name: name, value: string(rv.Bytes())})
+		}
+		for i := 0; i < rv.Len(); i++ {
+			dst = expandFormField(dst, name, rv.Index(i).Interface())
+		}
+		return dst
+	}
+
+	return append(dst, formPart{name: name, value: fmt.Sprint(rv.Interface())})
+}
+
+func filename(name string, reader io.Reader) string {
+	if named, ok := reader.(interface{ Name() string }); ok {
+		return path.Base(named.Name())
+	}
+	return name
+}
+
+func expandFormFields(fields []FormField) []formPart {
+	parts := make([]formPart, 0, len(fields))
+	for _, field := range fields {
+		parts = expandFormField(parts, field.Name, field.Value)
+	}
+	return parts
+}
+
+// EncodeForm encodes fields as 'application/x-www-form-urlencoded' values,
+// contents of file fields are read entirely as values
+func EncodeForm(fields ...FormField) (url.Values, error) {
+	values := make(url.Values, len(fields))
+	for _, part := range expandFormFields(fields) {
+		if part.file != nil {
+			content, err := io.ReadAll(part.file)
+			if err != nil {
+				return nil, fmt.Errorf("EncodeForm: error reading file %s: %w", part.name, err)
+			}
+			values.Add(part.name, string(content))
+		} else {
+			values.Add(part.name, part.value)
+		}
+	}
+	return values, nil
+}
+
+// NewMultipartForm encodes fields as 'multipart/form-data' body, which is
+// written in another goroutine so that files are streamed rather than
+// buffered, contentType contains the boundary and should be set as the
+// 'Content-Type' header of request, the goroutine starts at the first Read
+// of body, so that a body never sent (e.g. building request fails) holds
+// nothing, a body being read should be closed to stop the goroutine
+func NewMultipartForm(fields ...FormField) (contentType string, body io.ReadCloser) {
+	var (
+		parts  = expandFormFields(fields)
+		pr, pw = io.Pipe()
+		writer = multipart.NewWriter(pw)
+	)
+
+	return writer.FormDataContentType(), &multipartBody{
+		PipeReader: pr,
+		write: func() {
+			pw.CloseWithError(writeMultipart(writer, parts))
+		},
+	}
+}
+
+type multipartBody struct {
+	*io.PipeReader
+	once  sync.Once
+	write func()
+}
+
+func (body *multipartBody) Read(p []byte) (int, error) {
+	body.once.Do(func() {
+		go body.write()
+	})
+	return body.PipeReader.Read(p)
+}
+
+func (body *multipartBody) Close() error {
+	// writer is never started after Close
+	body.once.Do(func() {})
+	return body.PipeReader.Close()
+}
+
+func writeMultipart(writer *multipart.Writer, parts []formPart) error {
+	for _, part := range parts {
+		if part.file != nil {
+			w, err := writer.CreateFormFile(part.name, part.filename)
+			if err != nil {
+				return err
+			}
+			if _, err = io.Copy(w, part.file); err != nil {
+				return fmt.Errorf("NewMultipartForm: error copying file %s: %w", part.name, err)
+			}
+		} else if err := writer.WriteField(part.name, part.value); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
@@ -0,0 +1,110 @@
+package mrpkg
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type formArgType struct {
+	Name   string    `form:"name"`
+	Tags   []string  `form:"tag"`
+	Avatar io.Reader `file:"avatar"`
+	Ignore string
+}
+
+func TestEncodeForm(t *testing.T) {
+	got, err := EncodeForm(
+		FormField{Name: "arg", Value: &formArgType{
+			Name:   "user",
+			Tags:   []string{"a", "b"},
+			Avatar: strings.NewReader("content"),
+			Ignore: "ignore",
+		}},
+		FormField{Name: "id", Value: 1},
+		FormField{Name: "extra", Value: map[string]any{"k": "v"}},
+	)
+
+	if err != nil {
+		t.Fatalf("EncodeForm: %s", err)
+	}
+
+	expect := url.Values{
+		"name":   {"user"},
+		"tag":    {"a", "b"},
+		"avatar": {"content"},
+		"id":     {"1"},
+		"k":      {"v"},
+	}
+
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("EncodeForm: expect=%v; got=%v", expect, got)
+	}
+}
+
+func TestNewMultipartForm(t *testing.T) {
+	contentType, body := NewMultipartForm(
+		FormField{Name: "id", Value: 1},
+		FormField{Name: "file", Value: strings.NewReader("content")},
+	)
+	defer body.Close()
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("NewMultipartForm: invalid content type %q: %s", contentType, err)
+	}
+
+	form, err := multipart.NewReader(body, params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("NewMultipartForm: %s", err)
+	}
+
+	if got := form.Value["id"]; !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("NewMultipartForm: expect id=[1]; got=%v", got)
+	}
+
+	if files := form.File["file"]; len(files) != 1 || files[0].Filename != "file" {
+		t.Fatalf("NewMultipartForm: expect 1 file named 'file'; got=%v", files)
+	}
+
+	file, err := form.File["file"][0].Open()
+	if err != nil {
+		t.Fatalf("NewMultipartForm: %s", err)
+	}
+	defer file.Close()
+
+	if content, _ := io.ReadAll(file); string(content) != "content" {
+		t.Errorf("NewMultipartForm: expect file content=%q; got=%q", "content", content)
+	}
+}
+
+func TestNewMultipartFormClosedUnread(t *testing.T) {
+	file := &readRecorder{Reader: strings.NewReader("content")}
+	_, body := NewMultipartForm(FormField{Name: "file", Value: file})
+
+	if err := body.Close(); err != nil {
+		t.Fatalf("NewMultipartForm: %s", err)
+	}
+
+	if _, err := body.Read(make([]byte, 1)); err != io.ErrClosedPipe {
+		t.Errorf("NewMultipartForm: expect %v reading closed body; got %v", io.ErrClosedPipe, err)
+	}
+
+	if file.read {
+		t.Errorf("NewMultipartForm: file read after body closed unread")
+	}
+}
+
+type readRecorder struct {
+	io.Reader
+	read bool
+}
+
+func (r *readRecorder) Read(p []byte) (int, error) {
+	r.read = true
+	return r.Reader.Read(p)
+}
@@ -6,10 +6,13 @@ require (
 	github.com/jmoiron/sqlx v1.3.5
 	github.com/shopspring/decimal v1.3.1
 	github.com/spf13/cobra v1.6.0
+	github.com/spf13/pflag v1.0.5
 	golang.org/x/exp v0.0.0-20221012211006-4de253d81b95
+	golang.org/x/tools v0.2.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.0.1 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/mod v0.6.0 // indirect
+	golang.org/x/sys v0.1.0 // indirect
 )
@@ -0,0 +1,22 @@
+package mrpkg
+
+import "sync"
+
+// Lazy holds a value which is initialized by calling New at the first time
+// Get is called, it is safe to call Get from multiple goroutines
+type Lazy[T any] struct {
+	New   func() T
+	once  sync.Once
+	value T
+}
+
+func NewLazy[T any](New func() T) *Lazy[T] {
+	return &Lazy[T]{New: New}
+}
+
+func (lazy *Lazy[T]) Get() T {
+	lazy.once.Do(func() {
+		lazy.value = lazy.New()
+	})
+	return lazy.value
+}
@@ -0,0 +1,34 @@
+package mrpkg
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	var calls int
+	lazy := NewLazy(func() int {
+		calls++
+		return 42
+	})
+
+	if calls != 0 {
+		t.Fatalf("New called before Get")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := lazy.Get(); got != 42 {
+				t.Errorf("Lazy.Get: expects 42, got %d", got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("New expects to be called once, got %d", calls)
+	}
+}
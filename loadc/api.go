@@ -6,7 +6,6 @@ import (
 	"github.com/spf13/cobra"
 	"go/ast"
 	"go/format"
-	"go/parser"
 	"go/token"
 	"net/http"
 	"sort"
@@ -20,76 +19,105 @@ const (
 	ApiMethodInner    = "Inner"
 	ApiMethodResponse = "Response"
 
+	ApiFormMultipart  = "multipart/form-data"
+	ApiFormUrlencoded = "application/x-www-form-urlencoded"
+
 	FeatureApiCache  = "api/cache"
 	FeatureApiLog    = "api/log"
 	FeatureApiClient = "api/client"
+	FeatureApiRetry  = "api/retry"
+	FeatureApiMock   = "api/mock"
 )
 
 func genApi(_ *cobra.Command, _ []string) error {
-	inspectCtx, err := inspectApi(join(CurrentDir, CurrentFile), LineNum+1)
+	code, err := buildApi()
 	if err != nil {
-		return fmt.Errorf("inspectApi(%s, %d): %w", quote(join(CurrentDir, CurrentFile)), LineNum, err)
+		return err
 	}
 
-	if !checkResponse(inspectCtx.Methods) {
-		return fmt.Errorf("checkResponse: no '%s() T' method found in Interface", ApiMethodResponse)
+	if output == "" {
+		output = "api.go"
 	}
 
-	for _, method := range inspectCtx.Methods {
-		if method.Ident != ApiMethodResponse && method.Ident != ApiMethodInner {
-			if l := len(method.Out); l == 0 || !checkErrorType(method.Out[l-1]) {
-				return fmt.Errorf("checkErrorType: no 'error' found in method %s returned value",
-					quote(method.Ident))
-			}
-		}
+	if err = write(join(CurrentDir, output), code, FileMode); err != nil {
+		return fmt.Errorf("os.WriteFile(%s, %04x): %w", join(CurrentDir, output), FileMode, err)
+	}
 
-		if (method.Ident == ApiMethodResponse || method.Ident == ApiMethodInner) &&
-			(len(method.In) != 0 || len(method.Out) != 1) {
-			return fmt.Errorf(
-				"%s method can only have no income params "+
-					"and 1 returned value", quote(method.Ident))
-		}
+	return nil
+}
 
-		if method.Ident == ApiMethodResponse {
-			if !checkResponseType(method) {
-				return fmt.Errorf(
-					"checkResponseType: returned type of %s "+
-						"should be kind of *ast.Ident or *ast.StarExpr",
-					quote(ApiMethodResponse))
-			}
-		}
+// buildApi generates code for the interface declared at LineNum+1 of CurrentFile
+func buildApi() ([]byte, error) {
+	inspectCtx, err := inspectApi(join(CurrentDir, CurrentFile), LineNum+1)
+	if err != nil {
+		return nil, fmt.Errorf("inspectApi(%s, %d): %w", quote(join(CurrentDir, CurrentFile)), LineNum, err)
+	}
 
-		if len(method.Out) > 2 {
-			return fmt.Errorf("%s method expects 2 returned value at most, got %d",
-				quote(method.Ident),
-				len(method.Out))
+	if !checkResponse(inspectCtx.Methods) {
+		return nil, fmt.Errorf("checkResponse: no '%s() T' method found in Interface", ApiMethodResponse)
+	}
+
+	for _, method := range inspectCtx.Methods {
+		if err = checkApiMethod(method); err != nil {
+			return nil, fmt.Errorf("%s: %w", position(method.Pos), err)
 		}
 	}
 
 	code, err := genApiCode(inspectCtx)
 	if err != nil {
-		return fmt.Errorf("genApiCode: \n\n%#v\n\n%w", inspectCtx, err)
+		return nil, fmt.Errorf("genApiCode: \n\n%#v\n\n%w", inspectCtx, err)
 	}
 
 	fmtCode, err := format.Source(code)
 	if err != nil {
-		return fmt.Errorf("format.Source: \n\n%s\n\n%w", code, err)
+		return nil, fmt.Errorf("format.Source: \n\n%s\n\n%w", code, err)
 	}
 
-	if output == "" {
-		output = "api.go"
+	return dedupImports(fmtCode)
+}
+
+func checkApiMethod(method *Method) error {
+	if method.Ident != ApiMethodResponse && method.Ident != ApiMethodInner {
+		if l := len(method.Out); l == 0 || !checkErrorType(method.Out[l-1]) {
+			return fmt.Errorf("checkErrorType: no 'error' found in method %s returned value",
+				quote(method.Ident))
+		}
 	}
 
-	if err = write(join(CurrentDir, output), fmtCode, FileMode); err != nil {
-		return fmt.Errorf("os.WriteFile(%s, %04x): %w", join(CurrentDir, output), FileMode, err)
+	if (method.Ident == ApiMethodResponse || method.Ident == ApiMethodInner) &&
+		(len(method.In) != 0 || len(method.Out) != 1) {
+		return fmt.Errorf(
+			"%s method can only have no income params "+
+				"and 1 returned value", quote(method.Ident))
 	}
 
-	return nil
+	if method.Ident == ApiMethodResponse {
+		if !checkResponseType(method) {
+			return fmt.Errorf(
+				"checkResponseType: returned type of %s "+
+					"should be kind of *ast.Ident or *ast.StarExpr",
+				quote(ApiMethodResponse))
+		}
+	}
+
+	if method.MethodHTTP() == http.MethodHead && len(method.Out) != 1 {
+		return fmt.Errorf("%s method with %s request should only return 'error'",
+			quote(method.Ident),
+			http.MethodHead)
+	}
+
+	if len(method.Out) > 2 {
+		return fmt.Errorf("%s method expects 2 returned value at most, got %d",
+			quote(method.Ident),
+			len(method.Out))
+	}
+	return checkContextParam(method)
 }
 
 type ApiContext struct {
 	Package  string
 	Ident    string
+	Imports  []string
 	Generics map[string]ast.Expr
 	Methods  []*Method
 	Features []string
@@ -161,9 +189,7 @@ func (ctx *ApiContext) InnerType() ast.Node {
 }
 
 func inspectApi(file string, line int) (*ApiContext, error) {
-	fset := token.NewFileSet()
-
-	f, err := parser.ParseFile(fset, file, FileContent, parser.ParseComments)
+	fset, f, err := parseFile(file)
 	if err != nil {
 		return nil, err
 	}
@@ -213,8 +239,9 @@ inspectType:
 	for _, method := range ifaceType.Methods.List {
 		if !checkInput(method.Type.(*ast.FuncType)) {
 			return nil, fmt.Errorf(""+
-				"input params for method %s should "+
+				"%s: input params for method %s should "+
 				"contain 'Name' and 'Type' both",
+				position(method.Pos()),
 				quote(method.Names[0].Name))
 		}
 	}
@@ -235,13 +262,21 @@ inspectType:
 		}
 	}
 
+	methods := nodeMap(ifaceType.Methods.List, func(node ast.Node) *Method {
+		return inspectMethod(node, FileContent)
+	})
+
+	exprs := signatureExprs(methods)
+	for _, expr := range generics {
+		exprs = append(exprs, expr)
+	}
+
 	return &ApiContext{
 		Package:  PackageName,
 		Ident:    typeSpec.Name.Name,
+		Imports:  computeImports(f, exprs),
 		Generics: generics,
-		Methods: nodeMap(ifaceType.Methods.List, func(node ast.Node) *Method {
-			return inspectMethod(node, FileContent)
-		}),
+		Methods:  methods,
 		Features: apiFeatures,
 	}, nil
 }
@@ -313,14 +348,70 @@ func genApiCode(ctx *ApiContext) ([]byte, error) {
 			},
 			"httpMethodHasBody": func(method string) bool {
 				switch method {
-				case http.MethodGet:
+				case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+					return true
+				default:
 					return false
-				case http.MethodPost, http.MethodPut:
+				}
+			},
+			"argHasBody": func(method *Method) bool {
+				// a DELETE request seldom has a body, so we only take the last
+				// argument as its body when it is explicitly an 'io.Reader'
+				sortIn := method.SortIn()
+				if len(sortIn) == 0 {
+					return false
+				}
+				if method.MethodHTTP() != http.MethodDelete {
+					return true
+				}
+				return isReaderType(method.In[sortIn[len(sortIn)-1]], method.Source)
+			},
+			"lastArg": func(method *Method) string {
+				// lastArg is only used when argHasBody, which requires a param
+				sortIn := method.SortIn()
+				if len(sortIn) == 0 {
+					return "nil"
+				}
+				return sortIn[len(sortIn)-1]
+			},
+			"isIdempotent": func(method string) bool {
+				switch method {
+				case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
+					http.MethodOptions, http.MethodTrace:
 					return true
 				default:
 					return false
 				}
 			},
+			"isMultipart": func(method *Method) bool {
+				return method.FormType() == ApiFormMultipart
+			},
+			"isUrlencoded": func(method *Method) bool {
+				return method.FormType() == ApiFormUrlencoded
+			},
+			"formArgs": func(method *Method) []string {
+				args := make([]string, 0, len(method.In))
+				for _, ident := range method.SortIn() {
+					if !isContextType(ident, method.In[ident], method.Source) {
+						args = append(args, ident)
+					}
+				}
+				return args
+			},
+			"mockMethods": func(methods []*Method) []*Method {
+				mocks := make([]*Method, 0, len(methods))
+				for _, method := range methods {
+					if method.Ident != ApiMethodResponse &&
+						method.Ident != ApiMethodInner &&
+						len(method.MetaArgs()) > 1 {
+						mocks = append(mocks, method)
+					}
+				}
+				return mocks
+			},
+			"isHead": func(method string) bool {
+				return method == http.MethodHead
+			},
 			"headerHasBody": func(header string) bool {
 				if index := strings.Index(header, "\r\n\r\n"); index != -1 {
 					return len(header[index+4:]) > 0
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	CmdCheck = "check"
+
+	GoGeneratePrefix = "//go:generate "
+)
+
+var check = &cobra.Command{
+	Use:   CmdCheck + " [dir...]",
+	Short: "check that files generated by loadc are up to date",
+	Long: "" +
+		"check regenerates every loadc target in memory, including 'go:generate' lines running loadc\n" +
+		"and '//loadc:sqlx' or '//loadc:api' markers, then compares with files on disk, unified diff\n" +
+		"would be printed and check exits with non-zero code if any of them is out of date.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          checkPackages,
+}
+
+func checkPackages(cmd *cobra.Command, args []string) error {
+	// CurrentDir would be changed by buildMarker, so we keep the original one
+	workDir := CurrentDir
+
+	dirs := args
+	if len(dirs) == 0 {
+		dirs = []string{workDir}
+	}
+
+	var stale int
+	for _, dir := range dirs {
+		if !isAbs(dir) {
+			dir = join(workDir, dir)
+		}
+
+		if dir == "" {
+			dir = "."
+		}
+
+		pkgName, markers, err := inspectPackage(dir)
+		if err != nil {
+			return fmt.Errorf("inspectPackage(%s): %w", quote(dir), err)
+		}
+
+		generates, err := inspectGenerates(dir)
+		if err != nil {
+			return fmt.Errorf("inspectGenerates(%s): %w", quote(dir), err)
+		}
+
+		for _, marker := range append(generates, markers...) {
+			code, err := buildMarker(pkgName, dir, marker)
+			if err != nil {
+				return fmt.Errorf("check %s: %w", quote(marker.Output), err)
+			}
+
+			path := join(dir, marker.Output)
+			current, err := read(path)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("os.ReadFile(%s): %w", quote(path), err)
+			}
+
+			name := path
+			if rel, err := filepath.Rel(workDir, path); err == nil {
+				name = rel
+			}
+
+			if diff := unifiedDiff(name, current, code); diff != "" {
+				fmt.Fprint(cmd.OutOrStdout(), diff)
+				stale++
+			}
+		}
+	}
+
+	if stale > 0 {
+		return fmt.Errorf("%d generated file(s) out of date, run 'go generate' to update", stale)
+	}
+
+	return nil
+}
+
+// inspectGenerates finds all 'go:generate' lines running loadc in package,
+// lines running 'loadc generate' are skipped since markers are inspected
+// separately
+func inspectGenerates(dir string) ([]*Marker, error) {
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var generates []*Marker
+	for _, file := range pkg.Syntax {
+		for _, group := range file.Comments {
+			for _, comment := range group.List {
+				if !hasPrefix(comment.Text, GoGeneratePrefix) {
+					continue
+				}
+
+				generate, err := parseGenerate(comment.Text)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", pkg.Fset.Position(comment.Pos()), err)
+				}
+
+				if generate == nil {
+					continue
+				}
+
+				position := pkg.Fset.Position(comment.Pos())
+				generate.File = position.Filename
+				generate.Line = position.Line + 1
+				generates = append(generates, generate)
+			}
+		}
+	}
+
+	return generates, nil
+}
+
+// parseGenerate parses args of 'go:generate' line, returns nil if it does
+// not run loadc in single file mode
+func parseGenerate(text string) (*Marker, error) {
+	args := splitArgs(trimPrefix(text, GoGeneratePrefix))
+	for i, arg := range args {
+		if unquoted, err := strconv.Unquote(arg); err == nil {
+			args[i] = unquoted
+		}
+	}
+
+	var loadcArgs []string
+	for i, arg := range args {
+		if pkgPath, _, _ := strings.Cut(arg, "@"); pkgPath == "loadc" || hasSuffix(pkgPath, "/loadc") {
+			loadcArgs = args[i+1:]
+			break
+		}
+	}
+
+	if loadcArgs == nil || len(loadcArgs) > 0 && (loadcArgs[0] == CmdGenerate || loadcArgs[0] == CmdCheck) {
+		return nil, nil
+	}
+
+	target := &Marker{}
+	flags := pflag.NewFlagSet(GoGeneratePrefix, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVarP(&target.Mode, "mode", "m", "", "mode=[sql, api, sqlx, ...]")
+	flags.StringSliceVarP(&target.Features, "features", "f", nil, "features")
+	flags.StringVarP(&target.Output, "output", "o", "", "output file name")
+	flags.BoolVar(&target.Pointer, "pointer", false, "mode=sql: make 'SqlLoader' pointer type (*ident)")
+	flags.StringVar(&target.Schema, "schema", "", "mode=sqlx: validate sql against 'CREATE TABLE' statements in schema file")
+	flags.DurationVar(&target.Timeout, "timeout", 0, "mode=sqlx: default timeout of methods without 'TIMEOUT=<duration>' feature")
+	if err := flags.Parse(loadcArgs); err != nil {
+		return nil, err
+	}
+
+	target.Args = flags.Args()
+	switch target.Mode {
+	case ModeSql:
+		if len(target.Args) != 1 {
+			return nil, fmt.Errorf("mode %s expects 1 arg, got %d", quote(ModeSql), len(target.Args))
+		}
+	case ModeApi, ModeSqlx:
+	default:
+		return nil, nil
+	}
+
+	if err := checkFeatures(target.Features); err != nil {
+		return nil, err
+	}
+
+	if target.Output == "" {
+		target.Output = target.Mode + ".go"
+	}
+
+	return target, nil
+}
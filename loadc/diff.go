@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const DiffContext = 3
+
+const (
+	DiffEqual  = ' '
+	DiffDelete = '-'
+	DiffInsert = '+'
+)
+
+// diffOp represents one line in edit script, 'a' and 'b' are indexes of
+// the line in old and new content, for an inserted (or deleted) line,
+// 'a' (or 'b') is the index of the next line in old (or new) content
+type diffOp struct {
+	kind byte
+	a, b int
+}
+
+// unifiedDiff returns unified diff from old to new content of file name,
+// or empty string when they are identical
+func unifiedDiff(name string, old, new []byte) string {
+	if bytes.Equal(old, new) {
+		return ""
+	}
+
+	a, b := splitLines(old), splitLines(new)
+	ops := diffLines(a, b)
+
+	var dst strings.Builder
+	dst.WriteString("--- a/" + name + "\n")
+	dst.WriteString("+++ b/" + name + "\n")
+
+	for start := 0; start < len(ops); {
+		first := start
+		for first < len(ops) && ops[first].kind == DiffEqual {
+			first++
+		}
+
+		if first == len(ops) {
+			break
+		}
+
+		last := first
+		for next := first + 1; next < len(ops) && next-last <= 2*DiffContext; next++ {
+			if ops[next].kind != DiffEqual {
+				last = next
+			}
+		}
+
+		hunkStart, hunkEnd := first-DiffContext, last+DiffContext+1
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+
+		writeHunk(&dst, a, b, ops[hunkStart:hunkEnd])
+		start = hunkEnd
+	}
+
+	return dst.String()
+}
+
+func writeHunk(dst *strings.Builder, a, b []string, ops []diffOp) {
+	var oldCount, newCount int
+	for _, op := range ops {
+		if op.kind != DiffInsert {
+			oldCount++
+		}
+		if op.kind != DiffDelete {
+			newCount++
+		}
+	}
+
+	oldStart, newStart := ops[0].a+1, ops[0].b+1
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(dst, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, op := range ops {
+		var line string
+		switch op.kind {
+		case DiffInsert:
+			line = b[op.b]
+		default:
+			line = a[op.a]
+		}
+		dst.WriteByte(op.kind)
+		dst.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			dst.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes edit script from a to b by longest common subsequence,
+// common prefix and suffix are trimmed beforehand as generated files usually
+// differ in a few lines
+func diffLines(a, b []string) []diffOp {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	lcs := make([][]int32, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{kind: DiffEqual, a: i, b: i})
+	}
+
+	for i, j := 0, 0; i < len(ma) || j < len(mb); {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{kind: DiffEqual, a: prefix + i, b: prefix + j})
+			i++
+			j++
+		case i < len(ma) && (j == len(mb) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: DiffDelete, a: prefix + i, b: prefix + j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: DiffInsert, a: prefix + i, b: prefix + j})
+			j++
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, diffOp{kind: DiffEqual, a: len(a) - suffix + k, b: len(b) - suffix + k})
+	}
+
+	return ops
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+
+	"github.com/Boyux/mrpkg"
+)
+
+//go:generate go run "github.com/Boyux/mrpkg/loadc" generate
+
+type Order struct {
+	Id      int64 `db:"id"`
+	UserId  int64 `db:"user_id"`
+	Amount  int64 `db:"amount"`
+	Version int64 `db:"version"`
+}
+
+//loadc:sqlx --features=sqlx/log,sqlx/mock,sqlx/trace,sqlx/cache --schema=schema.sql --timeout=5s
+type OrderHandler interface {
+	WithTx(func(OrderHandler) error) error
+
+	// GetOrder QUERY PRIMARY
+	// SELECT id, user_id, amount, version FROM orders WHERE id = ?;
+	GetOrder(ctx context.Context, id int64) (*Order, error)
+
+	// ListOrders QUERY
+	// SELECT id, user_id, amount, version FROM orders WHERE user_id = ?;
+	ListOrders(ctx context.Context, userId int64) ([]*Order, error)
+
+	// ListOrdersByIds QUERY
+	// SELECT id, user_id, amount, version FROM orders WHERE id IN ({{ bindvars .ids }});
+	ListOrdersByIds(ctx context.Context, ids []int64) ([]*Order, error)
+
+	// IterateOrders QUERY
+	// SELECT id, user_id, amount, version FROM orders WHERE user_id = ?;
+	IterateOrders(ctx context.Context, userId int64) (mrpkg.ListIterator[*Order], error)
+
+	// CreateOrder EXEC INVALIDATES(ListOrders)
+	// INSERT INTO orders (user_id, amount) VALUES (?, ?);
+	CreateOrder(ctx context.Context, userId int64, amount int64) error
+
+	// UpdateOrderAmount EXEC VERSIONED INVALIDATES(GetOrder,ListOrders,ListOrdersByIds)
+	// UPDATE orders SET amount = ?, version = version + 1 WHERE id = ? AND version = ?;
+	UpdateOrderAmount(ctx context.Context, amount int64, id int64, version int64) error
+
+	// DeleteOrders EXEC AFFECTED
+	// DELETE FROM orders WHERE user_id = ?;
+	DeleteOrders(ctx context.Context, userId int64) (int64, error)
+}
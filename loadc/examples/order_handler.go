@@ -0,0 +1,880 @@
+// Code generated by loadc, DO NOT EDIT
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Boyux/mrpkg"
+	"github.com/jmoiron/sqlx"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+func NewOrderHandler(drv string, dsn string) OrderHandler {
+	return &implOrderHandler{
+		Core: sqlx.MustOpen(drv, dsn),
+	}
+}
+
+func NewOrderHandlerFromDB(core *sqlx.DB) OrderHandler {
+	return &implOrderHandler{
+		Core: core,
+	}
+}
+
+// NewOrderHandlerFromCluster routes QUERY methods to replicas in round-robin
+// order (replicas failing with connection errors are skipped for a while),
+// and routes EXEC methods, QUERY methods with PRIMARY feature and methods
+// called within WithTx to primary
+func NewOrderHandlerFromCluster(primary *sqlx.DB, replicas ...*sqlx.DB) OrderHandler {
+	return &implOrderHandler{
+		Core:     primary,
+		replicas: mrpkg.NewReplicas(replicas...),
+	}
+}
+
+func NewOrderHandlerFromCore(core interface {
+	Beginx() (*sqlx.Tx, error)
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+	PrepareNamed(query string) (*sqlx.NamedStmt, error)
+	PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error)
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
+	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+}) OrderHandler {
+	return &implOrderHandler{
+		Core: core,
+	}
+}
+
+type implOrderHandler struct {
+	withTx   bool
+	replicas *mrpkg.Replicas[*sqlx.DB]
+	Core     interface {
+		Beginx() (*sqlx.Tx, error)
+		BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+		PrepareNamed(query string) (*sqlx.NamedStmt, error)
+		PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error)
+		Exec(query string, args ...interface{}) (sql.Result, error)
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		Get(dest interface{}, query string, args ...interface{}) error
+		GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+		Select(dest interface{}, query string, args ...interface{}) error
+		SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+		Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
+		QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+	}
+}
+
+var sqlTmplOrderHandlerListOrdersByIds = mrpkg.NewLazy(func() *template.Template {
+	return template.Must(
+		template.
+			New("ListOrdersByIds").
+			Funcs(template.FuncMap{
+				"bindvars": mrpkg.GenBindVars,
+			}).
+			Parse("SELECT id, user_id, amount, version FROM orders WHERE id IN ({{ bindvars .ids }});\r\n\r\n"),
+	)
+})
+
+func (imp *implOrderHandler) GetOrder(ctx context.Context, id int64) (*Order, error) {
+	var (
+		v0GetOrder  = new(Order)
+		errGetOrder error
+	)
+
+	if cacheGetOrder, cacheOkGetOrder := imp.Core.(interface {
+		GetCache(string, ...any) []any
+	}); cacheOkGetOrder {
+		if cacheValuesGetOrder := cacheGetOrder.GetCache("GetOrder", id); len(cacheValuesGetOrder) == 1 {
+			// values of unexpected type (e.g. cached by an older version) are
+			// ignored, and the query runs as if cache misses
+			if cacheValueGetOrder, cacheValueOkGetOrder := cacheValuesGetOrder[0].(*Order); cacheValueOkGetOrder {
+				return cacheValueGetOrder, nil
+			}
+		}
+	}
+
+	ctx, cancelGetOrder := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelGetOrder()
+
+	sqlGetOrder := "SELECT id, user_id, amount, version FROM orders WHERE id = ?;\r\n\r\n"
+
+	sqlQueryGetOrder := strings.TrimSpace(sqlGetOrder)
+	argsGetOrder := mrpkg.MergeArgs(
+		id,
+	)
+
+	traceCtxGetOrder, endGetOrder := mrpkg.StartTrace(ctx, imp.Core, "GetOrder", sqlQueryGetOrder)
+
+	startGetOrder := time.Now()
+
+	errGetOrder = imp.Core.GetContext(traceCtxGetOrder, v0GetOrder, sqlQueryGetOrder, argsGetOrder...)
+
+	endGetOrder(errGetOrder)
+
+	if logGetOrder, okGetOrder := imp.Core.(interface {
+		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+	}); okGetOrder {
+		logGetOrder.Log(ctx, "GetOrder", sqlQueryGetOrder, mrpkg.RedactArgs(argsGetOrder), time.Since(startGetOrder))
+	}
+
+	if errGetOrder != nil {
+		return v0GetOrder, &mrpkg.QueryError{Caller: "GetOrder", Phase: mrpkg.PhaseExec, Query: sqlQueryGetOrder, Args: mrpkg.RedactArgs(argsGetOrder), Err: mrpkg.CheckTimeout(ctx, errGetOrder)}
+	}
+
+	if cacheGetOrder, cacheOkGetOrder := imp.Core.(interface {
+		SetCache(string, []any, ...any)
+	}); cacheOkGetOrder {
+		cacheGetOrder.SetCache(
+			"GetOrder",
+			[]any{id},
+			v0GetOrder)
+	}
+
+	return v0GetOrder, nil
+}
+
+func (imp *implOrderHandler) ListOrders(ctx context.Context, userId int64) ([]*Order, error) {
+	var (
+		v0ListOrders  []*Order
+		errListOrders error
+	)
+
+	if cacheListOrders, cacheOkListOrders := imp.Core.(interface {
+		GetCache(string, ...any) []any
+	}); cacheOkListOrders {
+		if cacheValuesListOrders := cacheListOrders.GetCache("ListOrders", userId); len(cacheValuesListOrders) == 1 {
+			// values of unexpected type (e.g. cached by an older version) are
+			// ignored, and the query runs as if cache misses
+			if cacheValueListOrders, cacheValueOkListOrders := cacheValuesListOrders[0].([]*Order); cacheValueOkListOrders {
+				return cacheValueListOrders, nil
+			}
+		}
+	}
+
+	ctx, cancelListOrders := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelListOrders()
+
+	sqlListOrders := "SELECT id, user_id, amount, version FROM orders WHERE user_id = ?;\r\n\r\n"
+
+	sqlQueryListOrders := strings.TrimSpace(sqlListOrders)
+	argsListOrders := mrpkg.MergeArgs(
+		userId,
+	)
+
+	coreListOrders, replicaListOrders := imp.Core, -1
+	if index, replica, ok := imp.replicas.Next(); ok {
+		coreListOrders, replicaListOrders = replica, index
+	}
+
+	traceCtxListOrders, endListOrders := mrpkg.StartTrace(ctx, imp.Core, "ListOrders", sqlQueryListOrders)
+
+	startListOrders := time.Now()
+
+	errListOrders = coreListOrders.SelectContext(traceCtxListOrders, &v0ListOrders, sqlQueryListOrders, argsListOrders...)
+
+	imp.replicas.Report(replicaListOrders, errListOrders)
+
+	endListOrders(errListOrders)
+
+	if logListOrders, okListOrders := imp.Core.(interface {
+		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+	}); okListOrders {
+		logListOrders.Log(ctx, "ListOrders", sqlQueryListOrders, mrpkg.RedactArgs(argsListOrders), time.Since(startListOrders))
+	}
+
+	if errListOrders != nil {
+		return v0ListOrders, &mrpkg.QueryError{Caller: "ListOrders", Phase: mrpkg.PhaseExec, Query: sqlQueryListOrders, Args: mrpkg.RedactArgs(argsListOrders), Err: mrpkg.CheckTimeout(ctx, errListOrders)}
+	}
+
+	if cacheListOrders, cacheOkListOrders := imp.Core.(interface {
+		SetCache(string, []any, ...any)
+	}); cacheOkListOrders {
+		cacheListOrders.SetCache(
+			"ListOrders",
+			[]any{userId},
+			v0ListOrders)
+	}
+
+	return v0ListOrders, nil
+}
+
+func (imp *implOrderHandler) ListOrdersByIds(ctx context.Context, ids []int64) ([]*Order, error) {
+	var (
+		v0ListOrdersByIds  []*Order
+		errListOrdersByIds error
+	)
+
+	if cacheListOrdersByIds, cacheOkListOrdersByIds := imp.Core.(interface {
+		GetCache(string, ...any) []any
+	}); cacheOkListOrdersByIds {
+		if cacheValuesListOrdersByIds := cacheListOrdersByIds.GetCache("ListOrdersByIds", ids); len(cacheValuesListOrdersByIds) == 1 {
+			// values of unexpected type (e.g. cached by an older version) are
+			// ignored, and the query runs as if cache misses
+			if cacheValueListOrdersByIds, cacheValueOkListOrdersByIds := cacheValuesListOrdersByIds[0].([]*Order); cacheValueOkListOrdersByIds {
+				return cacheValueListOrdersByIds, nil
+			}
+		}
+	}
+
+	ctx, cancelListOrdersByIds := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelListOrdersByIds()
+
+	bufListOrdersByIds := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(bufListOrdersByIds)
+	defer bufListOrdersByIds.Reset()
+
+	if errListOrdersByIds = sqlTmplOrderHandlerListOrdersByIds.Get().Execute(bufListOrdersByIds, map[string]any{
+		"ctx": ctx,
+		"ids": ids,
+	}); errListOrdersByIds != nil {
+		return v0ListOrdersByIds, &mrpkg.QueryError{Caller: "ListOrdersByIds", Phase: mrpkg.PhaseTemplate, Err: mrpkg.CheckTimeout(ctx, errListOrdersByIds)}
+	}
+
+	sqlListOrdersByIds := bufListOrdersByIds.String()
+
+	sqlQueryListOrdersByIds := strings.TrimSpace(sqlListOrdersByIds)
+	argsListOrdersByIds := mrpkg.MergeArgs(
+		ids,
+	)
+
+	coreListOrdersByIds, replicaListOrdersByIds := imp.Core, -1
+	if index, replica, ok := imp.replicas.Next(); ok {
+		coreListOrdersByIds, replicaListOrdersByIds = replica, index
+	}
+
+	traceCtxListOrdersByIds, endListOrdersByIds := mrpkg.StartTrace(ctx, imp.Core, "ListOrdersByIds", sqlQueryListOrdersByIds)
+
+	startListOrdersByIds := time.Now()
+
+	errListOrdersByIds = coreListOrdersByIds.SelectContext(traceCtxListOrdersByIds, &v0ListOrdersByIds, sqlQueryListOrdersByIds, argsListOrdersByIds...)
+
+	imp.replicas.Report(replicaListOrdersByIds, errListOrdersByIds)
+
+	endListOrdersByIds(errListOrdersByIds)
+
+	if logListOrdersByIds, okListOrdersByIds := imp.Core.(interface {
+		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+	}); okListOrdersByIds {
+		logListOrdersByIds.Log(ctx, "ListOrdersByIds", sqlQueryListOrdersByIds, mrpkg.RedactArgs(argsListOrdersByIds), time.Since(startListOrdersByIds))
+	}
+
+	if errListOrdersByIds != nil {
+		return v0ListOrdersByIds, &mrpkg.QueryError{Caller: "ListOrdersByIds", Phase: mrpkg.PhaseExec, Query: sqlQueryListOrdersByIds, Args: mrpkg.RedactArgs(argsListOrdersByIds), Err: mrpkg.CheckTimeout(ctx, errListOrdersByIds)}
+	}
+
+	if cacheListOrdersByIds, cacheOkListOrdersByIds := imp.Core.(interface {
+		SetCache(string, []any, ...any)
+	}); cacheOkListOrdersByIds {
+		cacheListOrdersByIds.SetCache(
+			"ListOrdersByIds",
+			[]any{ids},
+			v0ListOrdersByIds)
+	}
+
+	return v0ListOrdersByIds, nil
+}
+
+func (imp *implOrderHandler) IterateOrders(ctx context.Context, userId int64) (mrpkg.ListIterator[*Order], error) {
+	var (
+		v0IterateOrders  mrpkg.ListIterator[*Order]
+		errIterateOrders error
+	)
+
+	sqlIterateOrders := "SELECT id, user_id, amount, version FROM orders WHERE user_id = ?;\r\n\r\n"
+
+	sqlQueryIterateOrders := strings.TrimSpace(sqlIterateOrders)
+	argsIterateOrders := mrpkg.MergeArgs(
+		userId,
+	)
+
+	coreIterateOrders, replicaIterateOrders := imp.Core, -1
+	if index, replica, ok := imp.replicas.Next(); ok {
+		coreIterateOrders, replicaIterateOrders = replica, index
+	}
+
+	traceCtxIterateOrders, endIterateOrders := mrpkg.StartTrace(ctx, imp.Core, "IterateOrders", sqlQueryIterateOrders)
+
+	startIterateOrders := time.Now()
+
+	var rowsIterateOrders *sqlx.Rows
+
+	rowsIterateOrders, errIterateOrders = coreIterateOrders.QueryxContext(traceCtxIterateOrders, sqlQueryIterateOrders, argsIterateOrders...)
+
+	imp.replicas.Report(replicaIterateOrders, errIterateOrders)
+
+	endIterateOrders(errIterateOrders)
+
+	if logIterateOrders, okIterateOrders := imp.Core.(interface {
+		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+	}); okIterateOrders {
+		logIterateOrders.Log(ctx, "IterateOrders", sqlQueryIterateOrders, mrpkg.RedactArgs(argsIterateOrders), time.Since(startIterateOrders))
+	}
+
+	if errIterateOrders != nil {
+		return v0IterateOrders, &mrpkg.QueryError{Caller: "IterateOrders", Phase: mrpkg.PhaseExec, Query: sqlQueryIterateOrders, Args: mrpkg.RedactArgs(argsIterateOrders), Err: errIterateOrders}
+	}
+
+	v0IterateOrders = mrpkg.NewRowsIterator[*Order](ctx, rowsIterateOrders)
+
+	return v0IterateOrders, nil
+}
+
+func (imp *implOrderHandler) CreateOrder(ctx context.Context, userId int64, amount int64) error {
+	var (
+		errCreateOrder error
+	)
+
+	ctx, cancelCreateOrder := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelCreateOrder()
+
+	sqlCreateOrder := "INSERT INTO orders (user_id, amount) VALUES (?, ?);\r\n\r\n"
+
+	txCreateOrder, errCreateOrder := imp.Core.BeginTxx(ctx, nil)
+	if errCreateOrder != nil {
+		return &mrpkg.QueryError{Caller: "CreateOrder", Phase: mrpkg.PhaseBegin, Err: mrpkg.CheckTimeout(ctx, errCreateOrder)}
+	}
+	if !imp.withTx {
+		defer txCreateOrder.Rollback()
+	}
+
+	offsetCreateOrder := 0
+	argsCreateOrder := mrpkg.MergeArgs(
+		userId,
+		amount,
+	)
+
+	for _, splitSqlCreateOrder := range mrpkg.SplitSql(sqlCreateOrder) {
+
+		countCreateOrder := mrpkg.CountBindVars(splitSqlCreateOrder)
+
+		traceCtxCreateOrder, endCreateOrder := mrpkg.StartTrace(ctx, imp.Core, "CreateOrder", splitSqlCreateOrder)
+
+		startCreateOrder := time.Now()
+
+		resultCreateOrder, errCreateOrder := txCreateOrder.ExecContext(traceCtxCreateOrder, splitSqlCreateOrder, argsCreateOrder[offsetCreateOrder:offsetCreateOrder+countCreateOrder]...)
+
+		if errCreateOrder == nil {
+			mrpkg.TraceResult(traceCtxCreateOrder, imp.Core, resultCreateOrder)
+		}
+		endCreateOrder(errCreateOrder)
+
+		if logCreateOrder, okCreateOrder := imp.Core.(interface {
+			Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+		}); okCreateOrder {
+			logCreateOrder.Log(ctx, "CreateOrder", splitSqlCreateOrder, mrpkg.RedactArgs(argsCreateOrder), time.Since(startCreateOrder))
+		}
+
+		if errCreateOrder != nil {
+			return &mrpkg.QueryError{Caller: "CreateOrder", Phase: mrpkg.PhaseExec, Query: splitSqlCreateOrder, Args: mrpkg.RedactArgs(argsCreateOrder), Err: mrpkg.CheckTimeout(ctx, errCreateOrder)}
+		}
+
+		offsetCreateOrder += countCreateOrder
+	}
+
+	if !imp.withTx {
+		if errCreateOrder := txCreateOrder.Commit(); errCreateOrder != nil {
+			return &mrpkg.QueryError{Caller: "CreateOrder", Phase: mrpkg.PhaseCommit, Err: mrpkg.CheckTimeout(ctx, errCreateOrder)}
+		}
+	}
+
+	if cacheCreateOrder, cacheOkCreateOrder := imp.Core.(interface {
+		Invalidate(string, ...string)
+	}); cacheOkCreateOrder {
+		cacheCreateOrder.Invalidate("CreateOrder", "ListOrders")
+	}
+
+	return nil
+}
+
+func (imp *implOrderHandler) UpdateOrderAmount(ctx context.Context, amount int64, id int64, version int64) error {
+	var (
+		errUpdateOrderAmount error
+	)
+
+	ctx, cancelUpdateOrderAmount := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelUpdateOrderAmount()
+
+	sqlUpdateOrderAmount := "UPDATE orders SET amount = ?, version = version + 1 WHERE id = ? AND version = ?;\r\n\r\n"
+
+	txUpdateOrderAmount, errUpdateOrderAmount := imp.Core.BeginTxx(ctx, nil)
+	if errUpdateOrderAmount != nil {
+		return &mrpkg.QueryError{Caller: "UpdateOrderAmount", Phase: mrpkg.PhaseBegin, Err: mrpkg.CheckTimeout(ctx, errUpdateOrderAmount)}
+	}
+	if !imp.withTx {
+		defer txUpdateOrderAmount.Rollback()
+	}
+
+	offsetUpdateOrderAmount := 0
+	argsUpdateOrderAmount := mrpkg.MergeArgs(
+		amount,
+		id,
+		version,
+	)
+
+	for _, splitSqlUpdateOrderAmount := range mrpkg.SplitSql(sqlUpdateOrderAmount) {
+
+		countUpdateOrderAmount := mrpkg.CountBindVars(splitSqlUpdateOrderAmount)
+
+		traceCtxUpdateOrderAmount, endUpdateOrderAmount := mrpkg.StartTrace(ctx, imp.Core, "UpdateOrderAmount", splitSqlUpdateOrderAmount)
+
+		startUpdateOrderAmount := time.Now()
+
+		resultUpdateOrderAmount, errUpdateOrderAmount := txUpdateOrderAmount.ExecContext(traceCtxUpdateOrderAmount, splitSqlUpdateOrderAmount, argsUpdateOrderAmount[offsetUpdateOrderAmount:offsetUpdateOrderAmount+countUpdateOrderAmount]...)
+
+		if errUpdateOrderAmount == nil {
+			mrpkg.TraceResult(traceCtxUpdateOrderAmount, imp.Core, resultUpdateOrderAmount)
+		}
+		endUpdateOrderAmount(errUpdateOrderAmount)
+
+		if logUpdateOrderAmount, okUpdateOrderAmount := imp.Core.(interface {
+			Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+		}); okUpdateOrderAmount {
+			logUpdateOrderAmount.Log(ctx, "UpdateOrderAmount", splitSqlUpdateOrderAmount, mrpkg.RedactArgs(argsUpdateOrderAmount), time.Since(startUpdateOrderAmount))
+		}
+
+		if errUpdateOrderAmount != nil {
+			return &mrpkg.QueryError{Caller: "UpdateOrderAmount", Phase: mrpkg.PhaseExec, Query: splitSqlUpdateOrderAmount, Args: mrpkg.RedactArgs(argsUpdateOrderAmount), Err: mrpkg.CheckTimeout(ctx, errUpdateOrderAmount)}
+		}
+
+		if errUpdateOrderAmount = mrpkg.CheckVersion(resultUpdateOrderAmount); errUpdateOrderAmount != nil {
+			return &mrpkg.QueryError{Caller: "UpdateOrderAmount", Phase: mrpkg.PhaseResult, Query: splitSqlUpdateOrderAmount, Args: mrpkg.RedactArgs(argsUpdateOrderAmount), Err: mrpkg.CheckTimeout(ctx, errUpdateOrderAmount)}
+		}
+
+		offsetUpdateOrderAmount += countUpdateOrderAmount
+	}
+
+	if !imp.withTx {
+		if errUpdateOrderAmount := txUpdateOrderAmount.Commit(); errUpdateOrderAmount != nil {
+			return &mrpkg.QueryError{Caller: "UpdateOrderAmount", Phase: mrpkg.PhaseCommit, Err: mrpkg.CheckTimeout(ctx, errUpdateOrderAmount)}
+		}
+	}
+
+	if cacheUpdateOrderAmount, cacheOkUpdateOrderAmount := imp.Core.(interface {
+		Invalidate(string, ...string)
+	}); cacheOkUpdateOrderAmount {
+		cacheUpdateOrderAmount.Invalidate("UpdateOrderAmount", "GetOrder", "ListOrders", "ListOrdersByIds")
+	}
+
+	return nil
+}
+
+func (imp *implOrderHandler) DeleteOrders(ctx context.Context, userId int64) (int64, error) {
+	var (
+		v0DeleteOrders  int64
+		errDeleteOrders error
+	)
+
+	ctx, cancelDeleteOrders := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelDeleteOrders()
+
+	sqlDeleteOrders := "DELETE FROM orders WHERE user_id = ?;\r\n\r\n"
+
+	txDeleteOrders, errDeleteOrders := imp.Core.BeginTxx(ctx, nil)
+	if errDeleteOrders != nil {
+		return v0DeleteOrders, &mrpkg.QueryError{Caller: "DeleteOrders", Phase: mrpkg.PhaseBegin, Err: mrpkg.CheckTimeout(ctx, errDeleteOrders)}
+	}
+	if !imp.withTx {
+		defer txDeleteOrders.Rollback()
+	}
+
+	offsetDeleteOrders := 0
+	argsDeleteOrders := mrpkg.MergeArgs(
+		userId,
+	)
+
+	var resultsDeleteOrders mrpkg.Results
+
+	for _, splitSqlDeleteOrders := range mrpkg.SplitSql(sqlDeleteOrders) {
+
+		countDeleteOrders := mrpkg.CountBindVars(splitSqlDeleteOrders)
+
+		traceCtxDeleteOrders, endDeleteOrders := mrpkg.StartTrace(ctx, imp.Core, "DeleteOrders", splitSqlDeleteOrders)
+
+		startDeleteOrders := time.Now()
+
+		resultDeleteOrders, errDeleteOrders := txDeleteOrders.ExecContext(traceCtxDeleteOrders, splitSqlDeleteOrders, argsDeleteOrders[offsetDeleteOrders:offsetDeleteOrders+countDeleteOrders]...)
+
+		if errDeleteOrders == nil {
+			mrpkg.TraceResult(traceCtxDeleteOrders, imp.Core, resultDeleteOrders)
+		}
+		endDeleteOrders(errDeleteOrders)
+
+		if logDeleteOrders, okDeleteOrders := imp.Core.(interface {
+			Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+		}); okDeleteOrders {
+			logDeleteOrders.Log(ctx, "DeleteOrders", splitSqlDeleteOrders, mrpkg.RedactArgs(argsDeleteOrders), time.Since(startDeleteOrders))
+		}
+
+		if errDeleteOrders != nil {
+			return v0DeleteOrders, &mrpkg.QueryError{Caller: "DeleteOrders", Phase: mrpkg.PhaseExec, Query: splitSqlDeleteOrders, Args: mrpkg.RedactArgs(argsDeleteOrders), Err: mrpkg.CheckTimeout(ctx, errDeleteOrders)}
+		}
+
+		resultsDeleteOrders.Statements = append(resultsDeleteOrders.Statements, resultDeleteOrders)
+
+		offsetDeleteOrders += countDeleteOrders
+	}
+
+	if v0DeleteOrders, errDeleteOrders = resultsDeleteOrders.RowsAffected(); errDeleteOrders != nil {
+		return v0DeleteOrders, &mrpkg.QueryError{Caller: "DeleteOrders", Phase: mrpkg.PhaseResult, Query: sqlDeleteOrders, Args: mrpkg.RedactArgs(argsDeleteOrders), Err: mrpkg.CheckTimeout(ctx, errDeleteOrders)}
+	}
+
+	if !imp.withTx {
+		if errDeleteOrders := txDeleteOrders.Commit(); errDeleteOrders != nil {
+			return v0DeleteOrders, &mrpkg.QueryError{Caller: "DeleteOrders", Phase: mrpkg.PhaseCommit, Err: mrpkg.CheckTimeout(ctx, errDeleteOrders)}
+		}
+	}
+
+	return v0DeleteOrders, nil
+}
+
+// NewOrderHandlerFromTxAndLogAndCache runs methods within core, which is committed
+// or rolled back by caller, so tags of INVALIDATES feature are passed to
+// cache once EXEC methods succeed rather than after commit, cache could be
+// nil if there is nothing to invalidate
+func NewOrderHandlerFromTxAndLogAndCache(core *sqlx.Tx, log interface {
+	Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+}, cache interface{ Invalidate(string, ...string) }) OrderHandler {
+	return &implOrderHandler{
+		withTx: true,
+		Core: &txOrderHandler{
+			Tx:    core,
+			log:   log,
+			cache: cache,
+		},
+	}
+}
+
+type txOrderHandler struct {
+	*sqlx.Tx
+	savepoints    int
+	tracer        any
+	owned         bool
+	invalidations [][]string
+	cache         interface{ Invalidate(string, ...string) }
+	log           interface {
+		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+	}
+}
+
+func (tx txOrderHandler) Beginx() (*sqlx.Tx, error) {
+	return tx.Tx, nil
+}
+
+func (tx txOrderHandler) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return tx.Tx, nil
+}
+
+func (tx txOrderHandler) Log(ctx context.Context, caller string, query string, args any, elapse time.Duration) {
+	if tx.log != nil {
+		tx.log.Log(ctx, caller, query, args, elapse)
+	}
+}
+
+func (tx txOrderHandler) Start(ctx context.Context, caller string, query string) (context.Context, func(error)) {
+	return mrpkg.StartTrace(ctx, tx.tracer, caller, query)
+}
+
+func (tx txOrderHandler) TraceRowsAffected(ctx context.Context, rows int64) {
+	if tracer, ok := tx.tracer.(mrpkg.RowsAffectedTracer); ok {
+		tracer.TraceRowsAffected(ctx, rows)
+	}
+}
+
+// Invalidate records caller and tags invalidated within transaction, which
+// are passed to Invalidate of Core once the transaction is committed, so
+// that stale values are not cached again by queries running before commit,
+// queries within transaction never read or write cache, tags invalidated
+// within a savepoint are dropped if the savepoint is rolled back, while
+// tags invalidated within transaction not begun by WithTx are passed to
+// cache at once
+func (tx *txOrderHandler) Invalidate(caller string, tags ...string) {
+	if !tx.owned {
+		if tx.cache != nil {
+			tx.cache.Invalidate(caller, tags...)
+		}
+		return
+	}
+	tx.invalidations = append(tx.invalidations, append([]string{caller}, tags...))
+}
+
+// WithTx runs f in a transaction, which is committed if f returns nil, or
+// rolled back otherwise, calling WithTx within a transaction runs f in a
+// savepoint instead, so that only changes made by f are rolled back
+//
+// The transaction (or savepoint) is traced as a span of "WithTx" caller
+// if Core implements mrpkg.Tracer
+func (imp *implOrderHandler) WithTx(f func(OrderHandler) error) error {
+	if imp.withTx {
+		core := imp.Core.(*txOrderHandler)
+		core.savepoints++
+		invalidations := len(core.invalidations)
+		traceCtx, end := mrpkg.StartTrace(context.Background(), core, "WithTx", "SAVEPOINT")
+		err := mrpkg.Savepoint(traceCtx, core.Tx, fmt.Sprintf("savepoint_%d", core.savepoints), func() error {
+			return f(imp)
+		})
+		end(err)
+		if err != nil {
+			// writes within savepoint are rolled back, so are tags they invalidate
+			core.invalidations = core.invalidations[:invalidations]
+		}
+		return err
+	}
+
+	return imp.runTx(f)
+}
+
+// runTx runs f in a new transaction, see WithTx
+func (imp *implOrderHandler) runTx(f func(OrderHandler) error) (err error) {
+	traceCtx, end := mrpkg.StartTrace(context.Background(), imp.Core, "WithTx", "BEGIN")
+	defer func() {
+		end(err)
+	}()
+
+	inner, err := imp.Core.BeginTxx(traceCtx, nil)
+	if err != nil {
+		return &mrpkg.QueryError{Caller: "WithTx", Phase: mrpkg.PhaseBegin, Err: err}
+	}
+
+	defer inner.Rollback()
+
+	core := &txOrderHandler{
+		Tx:     inner,
+		tracer: imp.Core,
+		owned:  true,
+	}
+
+	if log, ok := imp.Core.(interface {
+		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+	}); ok {
+		core.log = log
+	}
+
+	tx := &implOrderHandler{
+		withTx: true,
+		Core:   core,
+	}
+
+	if err = f(tx); err != nil {
+		return err
+	}
+
+	if err = inner.Commit(); err != nil {
+		return &mrpkg.QueryError{Caller: "WithTx", Phase: mrpkg.PhaseCommit, Err: err}
+	}
+
+	if cache, ok := imp.Core.(interface{ Invalidate(string, ...string) }); ok {
+		for _, invalidation := range core.invalidations {
+			cache.Invalidate(invalidation[0], invalidation[1:]...)
+		}
+	}
+
+	return nil
+}
+
+var _ OrderHandler = (*MockOrderHandler)(nil)
+
+// MockOrderHandler is an in-memory implementation of OrderHandler for tests, each method
+// calls its '<Method>Func' field and records arguments into '<Method>Calls' field
+type MockOrderHandler struct {
+	mu sync.Mutex
+
+	GetOrderFunc  func(ctx context.Context, id int64) (*Order, error)
+	GetOrderCalls []MockOrderHandlerGetOrderCall
+
+	ListOrdersFunc  func(ctx context.Context, userId int64) ([]*Order, error)
+	ListOrdersCalls []MockOrderHandlerListOrdersCall
+
+	ListOrdersByIdsFunc  func(ctx context.Context, ids []int64) ([]*Order, error)
+	ListOrdersByIdsCalls []MockOrderHandlerListOrdersByIdsCall
+
+	IterateOrdersFunc  func(ctx context.Context, userId int64) (mrpkg.ListIterator[*Order], error)
+	IterateOrdersCalls []MockOrderHandlerIterateOrdersCall
+
+	CreateOrderFunc  func(ctx context.Context, userId int64, amount int64) error
+	CreateOrderCalls []MockOrderHandlerCreateOrderCall
+
+	UpdateOrderAmountFunc  func(ctx context.Context, amount int64, id int64, version int64) error
+	UpdateOrderAmountCalls []MockOrderHandlerUpdateOrderAmountCall
+
+	DeleteOrdersFunc  func(ctx context.Context, userId int64) (int64, error)
+	DeleteOrdersCalls []MockOrderHandlerDeleteOrdersCall
+
+	WithTxCalls []MockOrderHandlerWithTxCall
+}
+
+type MockOrderHandlerGetOrderCall struct {
+	Ctx context.Context
+	Id  int64
+}
+
+func (mock *MockOrderHandler) GetOrder(ctx context.Context, id int64) (*Order, error) {
+	mock.mu.Lock()
+	mock.GetOrderCalls = append(mock.GetOrderCalls, MockOrderHandlerGetOrderCall{
+		Ctx: ctx,
+		Id:  id,
+	})
+	funcGetOrder := mock.GetOrderFunc
+	mock.mu.Unlock()
+
+	if funcGetOrder == nil {
+		panic("MockOrderHandler.GetOrder: GetOrderFunc is nil")
+	}
+
+	return funcGetOrder(ctx, id)
+}
+
+type MockOrderHandlerListOrdersCall struct {
+	Ctx    context.Context
+	UserId int64
+}
+
+func (mock *MockOrderHandler) ListOrders(ctx context.Context, userId int64) ([]*Order, error) {
+	mock.mu.Lock()
+	mock.ListOrdersCalls = append(mock.ListOrdersCalls, MockOrderHandlerListOrdersCall{
+		Ctx:    ctx,
+		UserId: userId,
+	})
+	funcListOrders := mock.ListOrdersFunc
+	mock.mu.Unlock()
+
+	if funcListOrders == nil {
+		panic("MockOrderHandler.ListOrders: ListOrdersFunc is nil")
+	}
+
+	return funcListOrders(ctx, userId)
+}
+
+type MockOrderHandlerListOrdersByIdsCall struct {
+	Ctx context.Context
+	Ids []int64
+}
+
+func (mock *MockOrderHandler) ListOrdersByIds(ctx context.Context, ids []int64) ([]*Order, error) {
+	mock.mu.Lock()
+	mock.ListOrdersByIdsCalls = append(mock.ListOrdersByIdsCalls, MockOrderHandlerListOrdersByIdsCall{
+		Ctx: ctx,
+		Ids: ids,
+	})
+	funcListOrdersByIds := mock.ListOrdersByIdsFunc
+	mock.mu.Unlock()
+
+	if funcListOrdersByIds == nil {
+		panic("MockOrderHandler.ListOrdersByIds: ListOrdersByIdsFunc is nil")
+	}
+
+	return funcListOrdersByIds(ctx, ids)
+}
+
+type MockOrderHandlerIterateOrdersCall struct {
+	Ctx    context.Context
+	UserId int64
+}
+
+func (mock *MockOrderHandler) IterateOrders(ctx context.Context, userId int64) (mrpkg.ListIterator[*Order], error) {
+	mock.mu.Lock()
+	mock.IterateOrdersCalls = append(mock.IterateOrdersCalls, MockOrderHandlerIterateOrdersCall{
+		Ctx:    ctx,
+		UserId: userId,
+	})
+	funcIterateOrders := mock.IterateOrdersFunc
+	mock.mu.Unlock()
+
+	if funcIterateOrders == nil {
+		panic("MockOrderHandler.IterateOrders: IterateOrdersFunc is nil")
+	}
+
+	return funcIterateOrders(ctx, userId)
+}
+
+type MockOrderHandlerCreateOrderCall struct {
+	Ctx    context.Context
+	UserId int64
+	Amount int64
+}
+
+func (mock *MockOrderHandler) CreateOrder(ctx context.Context, userId int64, amount int64) error {
+	mock.mu.Lock()
+	mock.CreateOrderCalls = append(mock.CreateOrderCalls, MockOrderHandlerCreateOrderCall{
+		Ctx:    ctx,
+		UserId: userId,
+		Amount: amount,
+	})
+	funcCreateOrder := mock.CreateOrderFunc
+	mock.mu.Unlock()
+
+	if funcCreateOrder == nil {
+		panic("MockOrderHandler.CreateOrder: CreateOrderFunc is nil")
+	}
+
+	return funcCreateOrder(ctx, userId, amount)
+}
+
+type MockOrderHandlerUpdateOrderAmountCall struct {
+	Ctx     context.Context
+	Amount  int64
+	Id      int64
+	Version int64
+}
+
+func (mock *MockOrderHandler) UpdateOrderAmount(ctx context.Context, amount int64, id int64, version int64) error {
+	mock.mu.Lock()
+	mock.UpdateOrderAmountCalls = append(mock.UpdateOrderAmountCalls, MockOrderHandlerUpdateOrderAmountCall{
+		Ctx:     ctx,
+		Amount:  amount,
+		Id:      id,
+		Version: version,
+	})
+	funcUpdateOrderAmount := mock.UpdateOrderAmountFunc
+	mock.mu.Unlock()
+
+	if funcUpdateOrderAmount == nil {
+		panic("MockOrderHandler.UpdateOrderAmount: UpdateOrderAmountFunc is nil")
+	}
+
+	return funcUpdateOrderAmount(ctx, amount, id, version)
+}
+
+type MockOrderHandlerDeleteOrdersCall struct {
+	Ctx    context.Context
+	UserId int64
+}
+
+func (mock *MockOrderHandler) DeleteOrders(ctx context.Context, userId int64) (int64, error) {
+	mock.mu.Lock()
+	mock.DeleteOrdersCalls = append(mock.DeleteOrdersCalls, MockOrderHandlerDeleteOrdersCall{
+		Ctx:    ctx,
+		UserId: userId,
+	})
+	funcDeleteOrders := mock.DeleteOrdersFunc
+	mock.mu.Unlock()
+
+	if funcDeleteOrders == nil {
+		panic("MockOrderHandler.DeleteOrders: DeleteOrdersFunc is nil")
+	}
+
+	return funcDeleteOrders(ctx, userId)
+}
+
+type MockOrderHandlerWithTxCall struct {
+}
+
+// WithTx invokes f with mock itself, as there is no transaction in memory
+func (mock *MockOrderHandler) WithTx(f func(OrderHandler) error) error {
+	mock.mu.Lock()
+	mock.WithTxCalls = append(mock.WithTxCalls, MockOrderHandlerWithTxCall{})
+	mock.mu.Unlock()
+
+	return f(mock)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var errFake = errors.New("fake error")
+
+func init() {
+	sql.Register("fake", fakeDriver{})
+	sql.Register("fakeError", fakeDriver{err: errFake})
+}
+
+// fakeDriver runs every statement with err, or successfully if err is nil
+type fakeDriver struct {
+	err error
+}
+
+func (drv fakeDriver) Open(string) (driver.Conn, error) { return fakeConn(drv), nil }
+
+type fakeConn struct {
+	err error
+}
+
+func (conn fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt(conn), nil }
+func (fakeConn) Close() error                             { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	err error
+}
+
+// fakeStmtClosed counts statements closed by fakeDriver
+var fakeStmtClosed atomic.Int64
+
+func (fakeStmt) Close() error {
+	fakeStmtClosed.Add(1)
+	return nil
+}
+
+func (fakeStmt) NumInput() int { return -1 }
+
+func (stmt fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if stmt.err != nil {
+		return nil, stmt.err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (stmt fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if stmt.err != nil {
+		return nil, stmt.err
+	}
+	return nil, driver.ErrSkip
+}
+
+type fakeCache struct {
+	invalidations [][]string
+}
+
+func (cache *fakeCache) Invalidate(caller string, tags ...string) {
+	cache.invalidations = append(cache.invalidations, append([]string{caller}, tags...))
+}
+
+func TestOrderHandlerFromTxInvalidates(t *testing.T) {
+	db, err := sqlx.Open("fake", "")
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatalf("Beginx: %s", err)
+	}
+	defer tx.Rollback()
+
+	cache := new(fakeCache)
+	handler := NewOrderHandlerFromTxAndLogAndCache(tx, nil, cache)
+
+	if err = handler.CreateOrder(context.Background(), 1, 100); err != nil {
+		t.Fatalf("CreateOrder: %s", err)
+	}
+
+	if err = handler.WithTx(func(handler OrderHandler) error {
+		return handler.UpdateOrderAmount(context.Background(), 200, 1, 0)
+	}); err != nil {
+		t.Fatalf("WithTx: %s", err)
+	}
+
+	expect := [][]string{
+		{"CreateOrder", "ListOrders"},
+		{"UpdateOrderAmount", "GetOrder", "ListOrders", "ListOrdersByIds"},
+	}
+
+	if !reflect.DeepEqual(cache.invalidations, expect) {
+		t.Errorf("Invalidate: expect=%q; got=%q", expect, cache.invalidations)
+	}
+}
+
+type fakeCacheCore struct {
+	*sqlx.DB
+	fakeCache
+	values []any
+}
+
+func (core *fakeCacheCore) GetCache(string, ...any) []any  { return core.values }
+func (core *fakeCacheCore) SetCache(string, []any, ...any) {}
+
+func TestOrderHandlerCacheType(t *testing.T) {
+	db, err := sqlx.Open("fakeError", "")
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	defer db.Close()
+
+	core := &fakeCacheCore{DB: db, values: []any{&Order{Id: 1}}}
+	handler := NewOrderHandlerFromCore(core)
+
+	order, err := handler.GetOrder(context.Background(), 1)
+	if err != nil || order == nil || order.Id != 1 {
+		t.Fatalf("GetOrder: expect cached order, got %v, err=%v", order, err)
+	}
+
+	core.values = []any{Order{Id: 1}}
+	if _, err = handler.GetOrder(context.Background(), 1); !errors.Is(err, errFake) {
+		t.Errorf("GetOrder: expect query to run for cached value of unexpected type, got err=%v", err)
+	}
+}
+
+func TestOrderHandlerSavepointInvalidates(t *testing.T) {
+	db, err := sqlx.Open("fake", "")
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	defer db.Close()
+
+	var (
+		core     = &fakeCacheCore{DB: db}
+		handler  = NewOrderHandlerFromCore(core)
+		errInner = errors.New("inner")
+	)
+
+	if err = handler.WithTx(func(handler OrderHandler) error {
+		if err := handler.CreateOrder(context.Background(), 1, 100); err != nil {
+			return err
+		}
+		if err := handler.WithTx(func(handler OrderHandler) error {
+			if err := handler.UpdateOrderAmount(context.Background(), 200, 1, 0); err != nil {
+				return err
+			}
+			return errInner
+		}); !errors.Is(err, errInner) {
+			t.Errorf("WithTx: expect error %v, got %v", errInner, err)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("WithTx: %s", err)
+	}
+
+	expect := [][]string{{"CreateOrder", "ListOrders"}}
+	if !reflect.DeepEqual(core.invalidations, expect) {
+		t.Errorf("Invalidate: expect=%q; got=%q", expect, core.invalidations)
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"database/sql"
+	"github.com/Boyux/mrpkg"
+	"io"
 )
 
 type User struct {
@@ -29,15 +31,15 @@ func (update *UserUpdate) ToArgs() []any {
 	}
 }
 
-//go:generate go run "github.com/Boyux/mrpkg/loadc" --mode=sqlx --features=sqlx/log,sqlx/rebind --output=user_handler.go
+//go:generate go run "github.com/Boyux/mrpkg/loadc" --mode=sqlx --features=sqlx/log,sqlx/rebind,sqlx/mock,sqlx/retry --schema=schema.sql --output=user_handler.go
 type UserHandler interface {
-	WithTx(context.Context, func(UserHandler) error) error
+	WithTx(context.Context, *sql.TxOptions, func(UserHandler) error) error
 
 	// Get QUERY
 	// include sql/get_user.sql
 	Get(ctx context.Context, id int64) (*User, error)
 
-	// QueryByName QUERY NAMED
+	// QueryByName QUERY NAMED TIMEOUT=2s
 	// SELECT
 	//     id,
 	//     name
@@ -46,13 +48,34 @@ type UserHandler interface {
 	//     name = :name
 	QueryByName(name string) ([]User, error)
 
+	// Iterate QUERY
+	// SELECT id, name FROM user;
+	Iterate(ctx context.Context) (*mrpkg.RowsIterator[User], error)
+
+	// IterateByName QUERY NAMED
+	// SELECT id, name FROM user WHERE name = :name;
+	IterateByName(name string, f func(*User) error) error
+
 	// Update EXEC
 	// UPDATE user SET name = ? WHERE id = ?;
 	Update(ctx context.Context, user *UserUpdate) error
 
-	// UpdateName EXEC NAMED
+	// UpdateName EXEC NAMED REDACT(name)
 	// UPDATE user SET name = :name WHERE id = :id;
 	UpdateName(ctx context.Context, id int64, name string) (sql.Result, error)
+
+	// CreateUser EXEC LASTID REDACT(name)
+	// INSERT INTO user (name) VALUES (?);
+	CreateUser(ctx context.Context, name string) (int64, error)
+
+	// SwapNames EXEC
+	// UPDATE user SET name = ? WHERE id = ?;
+	// UPDATE user SET name = ? WHERE id = ?;
+	SwapNames(ctx context.Context, name1 string, id1 int64, name2 string, id2 int64) (*mrpkg.Results, error)
+
+	// InsertUsers EXEC BATCH
+	// INSERT INTO user (id, name) VALUES (?, ?);
+	InsertUsers(ctx context.Context, users []*UserUpdate) (int64, error)
 }
 
 type Inner struct {
@@ -66,7 +89,7 @@ func (*UserResponse) ScanValues(...any) error        { panic("unimplemented") }
 func (*UserResponse) FromBytes(string, []byte) error { panic("unimplemented") }
 func (*UserResponse) Break() bool                    { panic("unimplemented") }
 
-//go:generate go run "github.com/Boyux/mrpkg/loadc" --mode=api --features=api/cache,api/log,api/client --output=user_service.go
+//go:generate go run "github.com/Boyux/mrpkg/loadc" --mode=api --features=api/cache,api/log,api/client,api/retry,api/mock --output=user_service.go
 type UserService interface {
 	Inner() *Inner
 	Response() *UserResponse
@@ -85,6 +108,34 @@ type UserService interface {
 	//     "name": {{ $.user.Name }}
 	// }
 	UpdateUser(user *User) error
+
+	// PatchUser PATCH {{ $.UserService.Host }}/user/{{ $.id }}
+	// Content-Type: application/json
+	//
+	// {
+	//     "name": {{ $.name }}
+	// }
+	PatchUser(ctx context.Context, id int64, name string) error
+
+	// Touch PUT {{ $.UserService.Host }}/touch
+	Touch() error
+
+	// DeleteUser DELETE {{ $.UserService.Host }}/user/{{ $.id }}
+	DeleteUser(ctx context.Context, id int64) error
+
+	// ExportUsers GET {{ $.UserService.Host }}/users/export
+	ExportUsers(ctx context.Context) (io.ReadCloser, error)
+
+	// UploadAvatar POST {{ $.UserService.Host }}/user/{{ $.id }}/avatar
+	// Content-Type: multipart/form-data
+	UploadAvatar(ctx context.Context, id int64, avatar io.Reader) error
+
+	// SearchUsers POST {{ $.UserService.Host }}/users/search
+	// Content-Type: application/x-www-form-urlencoded
+	SearchUsers(name string, tags []string) ([]User, error)
+
+	// HasUser HEAD {{ $.UserService.Host }}/user/{{ $.id }}
+	HasUser(ctx context.Context, id int64) error
 }
 
 func main() {
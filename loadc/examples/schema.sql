@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user (
+    id   BIGINT      NOT NULL AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL DEFAULT '',
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id      BIGINT NOT NULL AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    amount  BIGINT NOT NULL DEFAULT 0,
+    version BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    KEY idx_user_id (user_id)
+);
@@ -3,15 +3,13 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
 	"github.com/Boyux/mrpkg"
 	"github.com/jmoiron/sqlx"
-	"strconv"
 	"strings"
-	"text/template"
+	"sync"
 	"time"
 )
 
@@ -27,6 +25,17 @@ func NewUserHandlerFromDB(core *sqlx.DB) UserHandler {
 	}
 }
 
+// NewUserHandlerFromCluster routes QUERY methods to replicas in round-robin
+// order (replicas failing with connection errors are skipped for a while),
+// and routes EXEC methods, QUERY methods with PRIMARY feature and methods
+// called within WithTx to primary
+func NewUserHandlerFromCluster(primary *sqlx.DB, replicas ...*sqlx.DB) UserHandler {
+	return &implUserHandler{
+		Core:     primary,
+		replicas: mrpkg.NewReplicas(replicas...),
+	}
+}
+
 func NewUserHandlerFromCore(core interface {
 	Rebind(query string) string
 	Beginx() (*sqlx.Tx, error)
@@ -39,6 +48,8 @@ func NewUserHandlerFromCore(core interface {
 	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 	Select(dest interface{}, query string, args ...interface{}) error
 	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
+	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
 }) UserHandler {
 	return &implUserHandler{
 		Core: core,
@@ -46,8 +57,11 @@ func NewUserHandlerFromCore(core interface {
 }
 
 type implUserHandler struct {
-	withTx bool
-	Core   interface {
+	withTx   bool
+	replicas *mrpkg.Replicas[*sqlx.DB]
+	mu       sync.RWMutex
+	stmts    map[int]map[string]*sqlx.NamedStmt
+	Core     interface {
 		Rebind(query string) string
 		Beginx() (*sqlx.Tx, error)
 		BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
@@ -59,7 +73,70 @@ type implUserHandler struct {
 		GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 		Select(dest interface{}, query string, args ...interface{}) error
 		SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+		Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
+		QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+	}
+}
+
+// prepareNamed returns prepared statement of query on replica at index
+// replica, or on Core if replica is negative, statements are cached in imp
+// so that each query is only prepared once per connection, they are
+// prepared without ctx of caller since they outlive the call, and failed
+// preparations are not cached
+func (imp *implUserHandler) prepareNamed(replica int, query string) (*sqlx.NamedStmt, error) {
+	imp.mu.RLock()
+	stmt, ok := imp.stmts[replica][query]
+	imp.mu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+
+	imp.mu.Lock()
+	defer imp.mu.Unlock()
+
+	if stmt, ok = imp.stmts[replica][query]; ok {
+		return stmt, nil
 	}
+
+	core := imp.Core
+	if replica >= 0 {
+		core = imp.replicas.At(replica)
+	}
+
+	stmt, err := core.PrepareNamedContext(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+
+	if imp.stmts == nil {
+		imp.stmts = make(map[int]map[string]*sqlx.NamedStmt)
+	}
+
+	if imp.stmts[replica] == nil {
+		imp.stmts[replica] = make(map[string]*sqlx.NamedStmt)
+	}
+
+	imp.stmts[replica][query] = stmt
+	return stmt, nil
+}
+
+// Close closes prepared statements cached by imp, Core and replicas are
+// not closed, imp prepares statements again if it is used after Close
+func (imp *implUserHandler) Close() error {
+	imp.mu.Lock()
+	defer imp.mu.Unlock()
+
+	var err error
+	for _, stmts := range imp.stmts {
+		for _, stmt := range stmts {
+			if closeErr := stmt.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	}
+
+	imp.stmts = nil
+	return err
 }
 
 func (imp *implUserHandler) Get(ctx context.Context, id int64) (*User, error) {
@@ -68,45 +145,34 @@ func (imp *implUserHandler) Get(ctx context.Context, id int64) (*User, error) {
 		errGet error
 	)
 
-	sqlTmplGet := template.Must(
-		template.
-			New("Get").
-			Funcs(template.FuncMap{
-				"bindvars": mrpkg.GenBindVars,
-			}).
-			Parse("SELECT *\nFROM user\nWHERE id = ?;\r\n\r\n"),
-	)
-
-	sqlGet := mrpkg.GetObj[*bytes.Buffer]()
-	defer mrpkg.PutObj(sqlGet)
-	defer sqlGet.Reset()
+	sqlGet := "SELECT *\nFROM user\nWHERE id = ?;\r\n\r\n"
 
-	if errGet = sqlTmplGet.Execute(sqlGet, map[string]any{
-		"ctx": ctx,
-		"id":  id,
-	}); errGet != nil {
-		return v0Get, fmt.Errorf("error executing %s template: %w", strconv.Quote("Get"), errGet)
-	}
-
-	sqlQueryGet := strings.TrimSpace(sqlGet.String())
+	sqlQueryGet := strings.TrimSpace(sqlGet)
 	sqlQueryGet = imp.Core.Rebind(sqlQueryGet)
 
 	argsGet := mrpkg.MergeArgs(
 		id,
 	)
 
+	coreGet, replicaGet := imp.Core, -1
+	if index, replica, ok := imp.replicas.Next(); ok {
+		coreGet, replicaGet = replica, index
+	}
+
 	startGet := time.Now()
 
-	errGet = imp.Core.GetContext(ctx, v0Get, sqlQueryGet, argsGet...)
+	errGet = coreGet.GetContext(ctx, v0Get, sqlQueryGet, argsGet...)
+
+	imp.replicas.Report(replicaGet, errGet)
 
 	if logGet, okGet := imp.Core.(interface {
 		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
 	}); okGet {
-		logGet.Log(ctx, "Get", sqlQueryGet, argsGet, time.Since(startGet))
+		logGet.Log(ctx, "Get", sqlQueryGet, mrpkg.RedactArgs(argsGet), time.Since(startGet))
 	}
 
 	if errGet != nil {
-		return v0Get, fmt.Errorf("error executing %s sql: \n\n%s\n\n%w", strconv.Quote("Get"), sqlQueryGet, errGet)
+		return v0Get, &mrpkg.QueryError{Caller: "Get", Phase: mrpkg.PhaseExec, Query: sqlQueryGet, Args: mrpkg.RedactArgs(argsGet), Err: errGet}
 	}
 
 	return v0Get, nil
@@ -118,81 +184,147 @@ func (imp *implUserHandler) QueryByName(name string) ([]User, error) {
 		errQueryByName error
 	)
 
-	sqlTmplQueryByName := template.Must(
-		template.
-			New("QueryByName").
-			Funcs(template.FuncMap{
-				"bindvars": mrpkg.GenBindVars,
-			}).
-			Parse("SELECT\r\nid,\r\nname\r\nFROM user\r\nWHERE\r\nname = :name\r\n\r\n"),
-	)
+	ctx, cancelQueryByName := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelQueryByName()
 
-	sqlQueryByName := mrpkg.GetObj[*bytes.Buffer]()
-	defer mrpkg.PutObj(sqlQueryByName)
-	defer sqlQueryByName.Reset()
+	sqlQueryByName := "SELECT\r\nid,\r\nname\r\nFROM user\r\nWHERE\r\nname = :name\r\n\r\n"
 
-	if errQueryByName = sqlTmplQueryByName.Execute(sqlQueryByName, map[string]any{
-		"name": name,
-	}); errQueryByName != nil {
-		return v0QueryByName, fmt.Errorf("error executing %s template: %w", strconv.Quote("QueryByName"), errQueryByName)
-	}
-
-	sqlQueryQueryByName := strings.TrimSpace(sqlQueryByName.String())
+	sqlQueryQueryByName := strings.TrimSpace(sqlQueryByName)
 	sqlQueryQueryByName = imp.Core.Rebind(sqlQueryQueryByName)
 
 	argsQueryByName := mrpkg.MergeNamedArgs(map[string]any{
 		"name": name,
 	})
 
+	replicaQueryByName := -1
+	if index, _, ok := imp.replicas.Next(); ok {
+		replicaQueryByName = index
+	}
+
 	startQueryByName := time.Now()
 
-	stmtQueryByName, errQueryByName := imp.Core.PrepareNamed(sqlQueryQueryByName)
+	stmtQueryByName, errQueryByName := imp.prepareNamed(replicaQueryByName, sqlQueryQueryByName)
 	if errQueryByName != nil {
-		return v0QueryByName, fmt.Errorf("error creating %s prepare statement: %w", strconv.Quote("QueryByName"), errQueryByName)
+		imp.replicas.Report(replicaQueryByName, errQueryByName)
+		return v0QueryByName, &mrpkg.QueryError{Caller: "QueryByName", Phase: mrpkg.PhasePrepare, Query: sqlQueryQueryByName, Args: mrpkg.RedactArgs(argsQueryByName), Err: mrpkg.CheckTimeout(ctx, errQueryByName)}
 	}
-	errQueryByName = stmtQueryByName.Select(&v0QueryByName, argsQueryByName)
+	errQueryByName = stmtQueryByName.SelectContext(ctx, &v0QueryByName, argsQueryByName)
+
+	imp.replicas.Report(replicaQueryByName, errQueryByName)
 
 	if logQueryByName, okQueryByName := imp.Core.(interface {
 		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
 	}); okQueryByName {
-		logQueryByName.Log(context.Background(), "QueryByName", sqlQueryQueryByName, argsQueryByName, time.Since(startQueryByName))
+		logQueryByName.Log(ctx, "QueryByName", sqlQueryQueryByName, mrpkg.RedactArgs(argsQueryByName), time.Since(startQueryByName))
 	}
 
 	if errQueryByName != nil {
-		return v0QueryByName, fmt.Errorf("error executing %s sql: \n\n%s\n\n%w", strconv.Quote("QueryByName"), sqlQueryQueryByName, errQueryByName)
+		return v0QueryByName, &mrpkg.QueryError{Caller: "QueryByName", Phase: mrpkg.PhaseExec, Query: sqlQueryQueryByName, Args: mrpkg.RedactArgs(argsQueryByName), Err: mrpkg.CheckTimeout(ctx, errQueryByName)}
 	}
 
 	return v0QueryByName, nil
 }
 
-func (imp *implUserHandler) Update(ctx context.Context, user *UserUpdate) error {
+func (imp *implUserHandler) Iterate(ctx context.Context) (*mrpkg.RowsIterator[User], error) {
 	var (
-		errUpdate error
+		v0Iterate  *mrpkg.RowsIterator[User]
+		errIterate error
 	)
 
-	sqlTmplUpdate := template.Must(
-		template.
-			New("Update").
-			Funcs(template.FuncMap{
-				"bindvars": mrpkg.GenBindVars,
-			}).
-			Parse("UPDATE user SET name = ? WHERE id = ?;\r\n\r\n"),
+	sqlIterate := "SELECT id, name FROM user;\r\n\r\n"
+
+	sqlQueryIterate := strings.TrimSpace(sqlIterate)
+	sqlQueryIterate = imp.Core.Rebind(sqlQueryIterate)
+
+	argsIterate := mrpkg.MergeArgs()
+
+	coreIterate, replicaIterate := imp.Core, -1
+	if index, replica, ok := imp.replicas.Next(); ok {
+		coreIterate, replicaIterate = replica, index
+	}
+
+	startIterate := time.Now()
+
+	var rowsIterate *sqlx.Rows
+
+	rowsIterate, errIterate = coreIterate.QueryxContext(ctx, sqlQueryIterate, argsIterate...)
+
+	imp.replicas.Report(replicaIterate, errIterate)
+
+	if logIterate, okIterate := imp.Core.(interface {
+		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+	}); okIterate {
+		logIterate.Log(ctx, "Iterate", sqlQueryIterate, mrpkg.RedactArgs(argsIterate), time.Since(startIterate))
+	}
+
+	if errIterate != nil {
+		return v0Iterate, &mrpkg.QueryError{Caller: "Iterate", Phase: mrpkg.PhaseExec, Query: sqlQueryIterate, Args: mrpkg.RedactArgs(argsIterate), Err: errIterate}
+	}
+
+	v0Iterate = mrpkg.NewRowsIterator[User](ctx, rowsIterate)
+
+	return v0Iterate, nil
+}
+
+func (imp *implUserHandler) IterateByName(name string, f func(*User) error) error {
+	var (
+		errIterateByName error
 	)
 
-	sqlUpdate := mrpkg.GetObj[*bytes.Buffer]()
-	defer mrpkg.PutObj(sqlUpdate)
-	defer sqlUpdate.Reset()
+	sqlIterateByName := "SELECT id, name FROM user WHERE name = :name;\r\n\r\n"
+
+	sqlQueryIterateByName := strings.TrimSpace(sqlIterateByName)
+	sqlQueryIterateByName = imp.Core.Rebind(sqlQueryIterateByName)
+
+	argsIterateByName := mrpkg.MergeNamedArgs(map[string]any{
+		"name": name,
+	})
+
+	replicaIterateByName := -1
+	if index, _, ok := imp.replicas.Next(); ok {
+		replicaIterateByName = index
+	}
+
+	startIterateByName := time.Now()
 
-	if errUpdate = sqlTmplUpdate.Execute(sqlUpdate, map[string]any{
-		"ctx":  ctx,
-		"user": user,
-	}); errUpdate != nil {
-		return fmt.Errorf("error executing %s template: %w", strconv.Quote("Update"), errUpdate)
+	var rowsIterateByName *sqlx.Rows
+
+	stmtIterateByName, errIterateByName := imp.prepareNamed(replicaIterateByName, sqlQueryIterateByName)
+	if errIterateByName != nil {
+		imp.replicas.Report(replicaIterateByName, errIterateByName)
+		return &mrpkg.QueryError{Caller: "IterateByName", Phase: mrpkg.PhasePrepare, Query: sqlQueryIterateByName, Args: mrpkg.RedactArgs(argsIterateByName), Err: errIterateByName}
 	}
+	rowsIterateByName, errIterateByName = stmtIterateByName.Queryx(argsIterateByName)
+
+	imp.replicas.Report(replicaIterateByName, errIterateByName)
+
+	if logIterateByName, okIterateByName := imp.Core.(interface {
+		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+	}); okIterateByName {
+		logIterateByName.Log(context.Background(), "IterateByName", sqlQueryIterateByName, mrpkg.RedactArgs(argsIterateByName), time.Since(startIterateByName))
+	}
+
+	if errIterateByName != nil {
+		return &mrpkg.QueryError{Caller: "IterateByName", Phase: mrpkg.PhaseExec, Query: sqlQueryIterateByName, Args: mrpkg.RedactArgs(argsIterateByName), Err: errIterateByName}
+	}
+
+	if errIterateByName = mrpkg.ScanRows(context.Background(), rowsIterateByName, f); errIterateByName != nil {
+		return &mrpkg.QueryError{Caller: "IterateByName", Phase: mrpkg.PhaseScan, Query: sqlQueryIterateByName, Args: mrpkg.RedactArgs(argsIterateByName), Err: errIterateByName}
+	}
+
+	return nil
+}
+
+func (imp *implUserHandler) Update(ctx context.Context, user *UserUpdate) error {
+	var (
+		errUpdate error
+	)
+
+	sqlUpdate := "UPDATE user SET name = ? WHERE id = ?;\r\n\r\n"
 
 	txUpdate, errUpdate := imp.Core.BeginTxx(ctx, nil)
 	if errUpdate != nil {
-		return fmt.Errorf("error creating %s transaction: %w", strconv.Quote("Update"), errUpdate)
+		return &mrpkg.QueryError{Caller: "Update", Phase: mrpkg.PhaseBegin, Err: errUpdate}
 	}
 	if !imp.withTx {
 		defer txUpdate.Rollback()
@@ -203,13 +335,9 @@ func (imp *implUserHandler) Update(ctx context.Context, user *UserUpdate) error
 		user,
 	)
 
-	for _, splitSqlUpdate := range strings.Split(sqlUpdate.String(), ";") {
-		splitSqlUpdate = strings.TrimSpace(splitSqlUpdate)
-		if splitSqlUpdate == "" {
-			continue
-		}
+	for _, splitSqlUpdate := range mrpkg.SplitSql(sqlUpdate) {
 
-		countUpdate := strings.Count(splitSqlUpdate, "?")
+		countUpdate := mrpkg.CountBindVars(splitSqlUpdate)
 		splitSqlUpdate = imp.Core.Rebind(splitSqlUpdate)
 
 		startUpdate := time.Now()
@@ -219,11 +347,11 @@ func (imp *implUserHandler) Update(ctx context.Context, user *UserUpdate) error
 		if logUpdate, okUpdate := imp.Core.(interface {
 			Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
 		}); okUpdate {
-			logUpdate.Log(ctx, "Update", splitSqlUpdate, argsUpdate, time.Since(startUpdate))
+			logUpdate.Log(ctx, "Update", splitSqlUpdate, mrpkg.RedactArgs(argsUpdate), time.Since(startUpdate))
 		}
 
 		if errUpdate != nil {
-			return fmt.Errorf("error executing %s sql: \n\n%s\n\n%w", strconv.Quote("Update"), splitSqlUpdate, errUpdate)
+			return &mrpkg.QueryError{Caller: "Update", Phase: mrpkg.PhaseExec, Query: splitSqlUpdate, Args: mrpkg.RedactArgs(argsUpdate), Err: errUpdate}
 		}
 
 		offsetUpdate += countUpdate
@@ -231,7 +359,7 @@ func (imp *implUserHandler) Update(ctx context.Context, user *UserUpdate) error
 
 	if !imp.withTx {
 		if errUpdate := txUpdate.Commit(); errUpdate != nil {
-			return fmt.Errorf("error committing %s transaction: %w", strconv.Quote("Update"), errUpdate)
+			return &mrpkg.QueryError{Caller: "Update", Phase: mrpkg.PhaseCommit, Err: errUpdate}
 		}
 	}
 
@@ -244,30 +372,11 @@ func (imp *implUserHandler) UpdateName(ctx context.Context, id int64, name strin
 		errUpdateName error
 	)
 
-	sqlTmplUpdateName := template.Must(
-		template.
-			New("UpdateName").
-			Funcs(template.FuncMap{
-				"bindvars": mrpkg.GenBindVars,
-			}).
-			Parse("UPDATE user SET name = :name WHERE id = :id;\r\n\r\n"),
-	)
-
-	sqlUpdateName := mrpkg.GetObj[*bytes.Buffer]()
-	defer mrpkg.PutObj(sqlUpdateName)
-	defer sqlUpdateName.Reset()
-
-	if errUpdateName = sqlTmplUpdateName.Execute(sqlUpdateName, map[string]any{
-		"ctx":  ctx,
-		"id":   id,
-		"name": name,
-	}); errUpdateName != nil {
-		return v0UpdateName, fmt.Errorf("error executing %s template: %w", strconv.Quote("UpdateName"), errUpdateName)
-	}
+	sqlUpdateName := "UPDATE user SET name = :name WHERE id = :id;\r\n\r\n"
 
 	txUpdateName, errUpdateName := imp.Core.BeginTxx(ctx, nil)
 	if errUpdateName != nil {
-		return v0UpdateName, fmt.Errorf("error creating %s transaction: %w", strconv.Quote("UpdateName"), errUpdateName)
+		return v0UpdateName, &mrpkg.QueryError{Caller: "UpdateName", Phase: mrpkg.PhaseBegin, Err: errUpdateName}
 	}
 	if !imp.withTx {
 		defer txUpdateName.Rollback()
@@ -278,43 +387,242 @@ func (imp *implUserHandler) UpdateName(ctx context.Context, id int64, name strin
 		"name": name,
 	})
 
-	for _, splitSqlUpdateName := range strings.Split(sqlUpdateName.String(), ";") {
-		splitSqlUpdateName = strings.TrimSpace(splitSqlUpdateName)
-		if splitSqlUpdateName == "" {
-			continue
-		}
+	redactedArgsUpdateName := mrpkg.RedactArgs(mrpkg.MergeNamedArgs(map[string]any{
+		"id":   id,
+		"name": mrpkg.Redacted,
+	}))
+
+	var resultsUpdateName mrpkg.Results
+
+	for _, splitSqlUpdateName := range mrpkg.SplitSql(sqlUpdateName) {
 		splitSqlUpdateName = imp.Core.Rebind(splitSqlUpdateName)
 
 		startUpdateName := time.Now()
 
-		stmtUpdateName, errUpdateName := txUpdateName.PrepareNamedContext(ctx, splitSqlUpdateName)
+		stmtUpdateName, errUpdateName := imp.prepareNamed(-1, splitSqlUpdateName)
 		if errUpdateName != nil {
-			return v0UpdateName, fmt.Errorf("error creating %s prepare statement: %w", strconv.Quote("UpdateName"), errUpdateName)
+			return v0UpdateName, &mrpkg.QueryError{Caller: "UpdateName", Phase: mrpkg.PhasePrepare, Query: splitSqlUpdateName, Args: redactedArgsUpdateName, Err: errUpdateName}
+		}
+
+		if !imp.withTx {
+			stmtUpdateName = txUpdateName.NamedStmtContext(ctx, stmtUpdateName)
 		}
 
-		v0UpdateName, errUpdateName = stmtUpdateName.ExecContext(ctx, argsUpdateName)
+		resultUpdateName, errUpdateName := stmtUpdateName.ExecContext(ctx, argsUpdateName)
 
 		if logUpdateName, okUpdateName := imp.Core.(interface {
 			Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
 		}); okUpdateName {
-			logUpdateName.Log(ctx, "UpdateName", splitSqlUpdateName, argsUpdateName, time.Since(startUpdateName))
+			logUpdateName.Log(ctx, "UpdateName", splitSqlUpdateName, redactedArgsUpdateName, time.Since(startUpdateName))
 		}
 
 		if errUpdateName != nil {
-			return v0UpdateName, fmt.Errorf("error executing %s sql: \n\n%s\n\n%w", strconv.Quote("UpdateName"), splitSqlUpdateName, errUpdateName)
+			return v0UpdateName, &mrpkg.QueryError{Caller: "UpdateName", Phase: mrpkg.PhaseExec, Query: splitSqlUpdateName, Args: redactedArgsUpdateName, Err: errUpdateName}
 		}
 
+		resultsUpdateName.Statements = append(resultsUpdateName.Statements, resultUpdateName)
+
+	}
+
+	if n := len(resultsUpdateName.Statements); n > 0 {
+		v0UpdateName = resultsUpdateName.Statements[n-1]
 	}
 
 	if !imp.withTx {
 		if errUpdateName := txUpdateName.Commit(); errUpdateName != nil {
-			return v0UpdateName, fmt.Errorf("error committing %s transaction: %w", strconv.Quote("UpdateName"), errUpdateName)
+			return v0UpdateName, &mrpkg.QueryError{Caller: "UpdateName", Phase: mrpkg.PhaseCommit, Err: errUpdateName}
 		}
 	}
 
 	return v0UpdateName, nil
 }
 
+func (imp *implUserHandler) CreateUser(ctx context.Context, name string) (int64, error) {
+	var (
+		v0CreateUser  int64
+		errCreateUser error
+	)
+
+	sqlCreateUser := "INSERT INTO user (name) VALUES (?);\r\n\r\n"
+
+	txCreateUser, errCreateUser := imp.Core.BeginTxx(ctx, nil)
+	if errCreateUser != nil {
+		return v0CreateUser, &mrpkg.QueryError{Caller: "CreateUser", Phase: mrpkg.PhaseBegin, Err: errCreateUser}
+	}
+	if !imp.withTx {
+		defer txCreateUser.Rollback()
+	}
+
+	offsetCreateUser := 0
+	argsCreateUser := mrpkg.MergeArgs(
+		name,
+	)
+
+	redactedArgsCreateUser := mrpkg.RedactArgs(mrpkg.MergeArgs(
+		mrpkg.Redacted,
+	))
+
+	var resultsCreateUser mrpkg.Results
+
+	for _, splitSqlCreateUser := range mrpkg.SplitSql(sqlCreateUser) {
+
+		countCreateUser := mrpkg.CountBindVars(splitSqlCreateUser)
+		splitSqlCreateUser = imp.Core.Rebind(splitSqlCreateUser)
+
+		startCreateUser := time.Now()
+
+		resultCreateUser, errCreateUser := txCreateUser.ExecContext(ctx, splitSqlCreateUser, argsCreateUser[offsetCreateUser:offsetCreateUser+countCreateUser]...)
+
+		if logCreateUser, okCreateUser := imp.Core.(interface {
+			Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+		}); okCreateUser {
+			logCreateUser.Log(ctx, "CreateUser", splitSqlCreateUser, redactedArgsCreateUser, time.Since(startCreateUser))
+		}
+
+		if errCreateUser != nil {
+			return v0CreateUser, &mrpkg.QueryError{Caller: "CreateUser", Phase: mrpkg.PhaseExec, Query: splitSqlCreateUser, Args: redactedArgsCreateUser, Err: errCreateUser}
+		}
+
+		resultsCreateUser.Statements = append(resultsCreateUser.Statements, resultCreateUser)
+
+		offsetCreateUser += countCreateUser
+	}
+
+	if v0CreateUser, errCreateUser = resultsCreateUser.LastInsertId(); errCreateUser != nil {
+		return v0CreateUser, &mrpkg.QueryError{Caller: "CreateUser", Phase: mrpkg.PhaseResult, Query: sqlCreateUser, Args: redactedArgsCreateUser, Err: errCreateUser}
+	}
+
+	if !imp.withTx {
+		if errCreateUser := txCreateUser.Commit(); errCreateUser != nil {
+			return v0CreateUser, &mrpkg.QueryError{Caller: "CreateUser", Phase: mrpkg.PhaseCommit, Err: errCreateUser}
+		}
+	}
+
+	return v0CreateUser, nil
+}
+
+func (imp *implUserHandler) SwapNames(ctx context.Context, name1 string, id1 int64, name2 string, id2 int64) (*mrpkg.Results, error) {
+	var (
+		v0SwapNames  = new(mrpkg.Results)
+		errSwapNames error
+	)
+
+	sqlSwapNames := "UPDATE user SET name = ? WHERE id = ?;\r\nUPDATE user SET name = ? WHERE id = ?;\r\n\r\n"
+
+	txSwapNames, errSwapNames := imp.Core.BeginTxx(ctx, nil)
+	if errSwapNames != nil {
+		return v0SwapNames, &mrpkg.QueryError{Caller: "SwapNames", Phase: mrpkg.PhaseBegin, Err: errSwapNames}
+	}
+	if !imp.withTx {
+		defer txSwapNames.Rollback()
+	}
+
+	offsetSwapNames := 0
+	argsSwapNames := mrpkg.MergeArgs(
+		name1,
+		id1,
+		name2,
+		id2,
+	)
+
+	var resultsSwapNames mrpkg.Results
+
+	for _, splitSqlSwapNames := range mrpkg.SplitSql(sqlSwapNames) {
+
+		countSwapNames := mrpkg.CountBindVars(splitSqlSwapNames)
+		splitSqlSwapNames = imp.Core.Rebind(splitSqlSwapNames)
+
+		startSwapNames := time.Now()
+
+		resultSwapNames, errSwapNames := txSwapNames.ExecContext(ctx, splitSqlSwapNames, argsSwapNames[offsetSwapNames:offsetSwapNames+countSwapNames]...)
+
+		if logSwapNames, okSwapNames := imp.Core.(interface {
+			Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+		}); okSwapNames {
+			logSwapNames.Log(ctx, "SwapNames", splitSqlSwapNames, mrpkg.RedactArgs(argsSwapNames), time.Since(startSwapNames))
+		}
+
+		if errSwapNames != nil {
+			return v0SwapNames, &mrpkg.QueryError{Caller: "SwapNames", Phase: mrpkg.PhaseExec, Query: splitSqlSwapNames, Args: mrpkg.RedactArgs(argsSwapNames), Err: errSwapNames}
+		}
+
+		resultsSwapNames.Statements = append(resultsSwapNames.Statements, resultSwapNames)
+
+		offsetSwapNames += countSwapNames
+	}
+
+	v0SwapNames = &resultsSwapNames
+
+	if !imp.withTx {
+		if errSwapNames := txSwapNames.Commit(); errSwapNames != nil {
+			return v0SwapNames, &mrpkg.QueryError{Caller: "SwapNames", Phase: mrpkg.PhaseCommit, Err: errSwapNames}
+		}
+	}
+
+	return v0SwapNames, nil
+}
+
+func (imp *implUserHandler) InsertUsers(ctx context.Context, users []*UserUpdate) (int64, error) {
+	var (
+		v0InsertUsers  int64
+		errInsertUsers error
+	)
+
+	sqlInsertUsers := "INSERT INTO user (id, name) VALUES (?, ?);\r\n\r\n"
+
+	txInsertUsers, errInsertUsers := imp.Core.BeginTxx(ctx, nil)
+	if errInsertUsers != nil {
+		return v0InsertUsers, &mrpkg.QueryError{Caller: "InsertUsers", Phase: mrpkg.PhaseBegin, Err: errInsertUsers}
+	}
+	if !imp.withTx {
+		defer txInsertUsers.Rollback()
+	}
+
+	batchSqlInsertUsers := strings.TrimSuffix(strings.TrimSpace(sqlInsertUsers), ";")
+	for _, chunkInsertUsers := range mrpkg.Chunk(users, mrpkg.BatchSize(mrpkg.CountBindVars(batchSqlInsertUsers))) {
+		if len(chunkInsertUsers) == 0 {
+			continue
+		}
+
+		splitSqlInsertUsers, errInsertUsers := mrpkg.ExpandValues(batchSqlInsertUsers, len(chunkInsertUsers))
+		if errInsertUsers != nil {
+			return v0InsertUsers, &mrpkg.QueryError{Caller: "InsertUsers", Phase: mrpkg.PhaseTemplate, Query: batchSqlInsertUsers, Err: errInsertUsers}
+		}
+		splitSqlInsertUsers = imp.Core.Rebind(splitSqlInsertUsers)
+
+		argsInsertUsers := mrpkg.MergeBatchArgs(chunkInsertUsers)
+
+		startInsertUsers := time.Now()
+
+		resultInsertUsers, errInsertUsers := txInsertUsers.ExecContext(ctx, splitSqlInsertUsers, argsInsertUsers...)
+
+		if logInsertUsers, okInsertUsers := imp.Core.(interface {
+			Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+		}); okInsertUsers {
+			logInsertUsers.Log(ctx, "InsertUsers", splitSqlInsertUsers, mrpkg.RedactArgs(argsInsertUsers), time.Since(startInsertUsers))
+		}
+
+		if errInsertUsers != nil {
+			return v0InsertUsers, &mrpkg.QueryError{Caller: "InsertUsers", Phase: mrpkg.PhaseExec, Query: splitSqlInsertUsers, Args: mrpkg.RedactArgs(argsInsertUsers), Err: errInsertUsers}
+		}
+
+		rowsAffectedInsertUsers, errInsertUsers := resultInsertUsers.RowsAffected()
+		if errInsertUsers != nil {
+			return v0InsertUsers, &mrpkg.QueryError{Caller: "InsertUsers", Phase: mrpkg.PhaseResult, Query: splitSqlInsertUsers, Args: mrpkg.RedactArgs(argsInsertUsers), Err: errInsertUsers}
+		}
+
+		v0InsertUsers += rowsAffectedInsertUsers
+	}
+
+	if !imp.withTx {
+		if errInsertUsers := txInsertUsers.Commit(); errInsertUsers != nil {
+			return v0InsertUsers, &mrpkg.QueryError{Caller: "InsertUsers", Phase: mrpkg.PhaseCommit, Err: errInsertUsers}
+		}
+	}
+
+	return v0InsertUsers, nil
+}
+
 func NewUserHandlerFromTxAndLog(core *sqlx.Tx, log interface {
 	Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
 }) UserHandler {
@@ -329,7 +637,8 @@ func NewUserHandlerFromTxAndLog(core *sqlx.Tx, log interface {
 
 type txUserHandler struct {
 	*sqlx.Tx
-	log interface {
+	savepoints int
+	log        interface {
 		Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
 	}
 }
@@ -348,10 +657,73 @@ func (tx txUserHandler) Log(ctx context.Context, caller string, query string, ar
 	}
 }
 
-func (imp *implUserHandler) WithTx(ctx context.Context, f func(UserHandler) error) error {
-	inner, err := imp.Core.BeginTxx(ctx, nil)
+// WithTx runs f in a transaction, which is committed if f returns nil, or
+// rolled back otherwise, calling WithTx within a transaction runs f in a
+// savepoint instead, so that only changes made by f are rolled back
+// (opts does not apply to savepoints)
+//
+// If Core implements 'Retryable(error) bool', a transaction failed with
+// retryable error is retried with a fresh transaction, which calls f
+// again, retry policy is provided by Core with method
+// 'RetryPolicy() (int, func(int) time.Duration)', or defaults to
+// mrpkg.DefaultRetryMax and mrpkg.DefaultRetryBackoff
+func (imp *implUserHandler) WithTx(ctx context.Context, opts *sql.TxOptions, f func(UserHandler) error) error {
+	if imp.withTx {
+		core := imp.Core.(*txUserHandler)
+		core.savepoints++
+		err := mrpkg.Savepoint(ctx, core.Tx, fmt.Sprintf("savepoint_%d", core.savepoints), func() error {
+			return f(imp)
+		})
+
+		return err
+	}
+
+	retrier, ok := imp.Core.(interface{ Retryable(error) bool })
+	if !ok {
+		return imp.runTx(ctx, opts, f)
+	}
+
+	retryMax, retryBackoff := mrpkg.DefaultRetryMax, mrpkg.DefaultRetryBackoff
+	if policy, ok := imp.Core.(interface {
+		RetryPolicy() (int, func(int) time.Duration)
+	}); ok {
+		retryMax, retryBackoff = policy.RetryPolicy()
+	}
+
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		err := imp.runTx(ctx, opts, f)
+		if err == nil || attempt >= retryMax || !retrier.Retryable(err) {
+			return err
+		}
+
+		if log, ok := imp.Core.(interface {
+			Log(ctx context.Context, caller string, query string, args any, elapse time.Duration)
+		}); ok {
+			log.Log(ctx, "WithTx", "ROLLBACK", mrpkg.RedactArgs(map[string]any{
+				"attempt": attempt + 1,
+				"error":   err.Error(),
+			}), time.Since(start))
+		}
+
+		var delay time.Duration
+		if retryBackoff != nil {
+			delay = retryBackoff(attempt)
+		}
+
+		select {
+		case <-ctx.Done():
+			return &mrpkg.QueryError{Caller: "WithTx", Phase: mrpkg.PhaseBegin, Err: ctx.Err()}
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runTx runs f in a new transaction, see WithTx
+func (imp *implUserHandler) runTx(ctx context.Context, opts *sql.TxOptions, f func(UserHandler) error) error {
+	inner, err := imp.Core.BeginTxx(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("error creating transaction in %s: %w", strconv.Quote("WithTx"), err)
+		return &mrpkg.QueryError{Caller: "WithTx", Phase: mrpkg.PhaseBegin, Err: err}
 	}
 
 	defer inner.Rollback()
@@ -376,8 +748,255 @@ func (imp *implUserHandler) WithTx(ctx context.Context, f func(UserHandler) erro
 	}
 
 	if err = inner.Commit(); err != nil {
-		return fmt.Errorf("error committing transaction in %s: %w", strconv.Quote("WithTx"), err)
+		return &mrpkg.QueryError{Caller: "WithTx", Phase: mrpkg.PhaseCommit, Err: err}
 	}
 
 	return nil
 }
+
+var _ UserHandler = (*MockUserHandler)(nil)
+
+// MockUserHandler is an in-memory implementation of UserHandler for tests, each method
+// calls its '<Method>Func' field and records arguments into '<Method>Calls' field
+type MockUserHandler struct {
+	mu sync.Mutex
+
+	GetFunc  func(ctx context.Context, id int64) (*User, error)
+	GetCalls []MockUserHandlerGetCall
+
+	QueryByNameFunc  func(name string) ([]User, error)
+	QueryByNameCalls []MockUserHandlerQueryByNameCall
+
+	IterateFunc  func(ctx context.Context) (*mrpkg.RowsIterator[User], error)
+	IterateCalls []MockUserHandlerIterateCall
+
+	IterateByNameFunc  func(name string, f func(*User) error) error
+	IterateByNameCalls []MockUserHandlerIterateByNameCall
+
+	UpdateFunc  func(ctx context.Context, user *UserUpdate) error
+	UpdateCalls []MockUserHandlerUpdateCall
+
+	UpdateNameFunc  func(ctx context.Context, id int64, name string) (sql.Result, error)
+	UpdateNameCalls []MockUserHandlerUpdateNameCall
+
+	CreateUserFunc  func(ctx context.Context, name string) (int64, error)
+	CreateUserCalls []MockUserHandlerCreateUserCall
+
+	SwapNamesFunc  func(ctx context.Context, name1 string, id1 int64, name2 string, id2 int64) (*mrpkg.Results, error)
+	SwapNamesCalls []MockUserHandlerSwapNamesCall
+
+	InsertUsersFunc  func(ctx context.Context, users []*UserUpdate) (int64, error)
+	InsertUsersCalls []MockUserHandlerInsertUsersCall
+
+	WithTxCalls []MockUserHandlerWithTxCall
+}
+
+type MockUserHandlerGetCall struct {
+	Ctx context.Context
+	Id  int64
+}
+
+func (mock *MockUserHandler) Get(ctx context.Context, id int64) (*User, error) {
+	mock.mu.Lock()
+	mock.GetCalls = append(mock.GetCalls, MockUserHandlerGetCall{
+		Ctx: ctx,
+		Id:  id,
+	})
+	funcGet := mock.GetFunc
+	mock.mu.Unlock()
+
+	if funcGet == nil {
+		panic("MockUserHandler.Get: GetFunc is nil")
+	}
+
+	return funcGet(ctx, id)
+}
+
+type MockUserHandlerQueryByNameCall struct {
+	Name string
+}
+
+func (mock *MockUserHandler) QueryByName(name string) ([]User, error) {
+	mock.mu.Lock()
+	mock.QueryByNameCalls = append(mock.QueryByNameCalls, MockUserHandlerQueryByNameCall{
+		Name: name,
+	})
+	funcQueryByName := mock.QueryByNameFunc
+	mock.mu.Unlock()
+
+	if funcQueryByName == nil {
+		panic("MockUserHandler.QueryByName: QueryByNameFunc is nil")
+	}
+
+	return funcQueryByName(name)
+}
+
+type MockUserHandlerIterateCall struct {
+	Ctx context.Context
+}
+
+func (mock *MockUserHandler) Iterate(ctx context.Context) (*mrpkg.RowsIterator[User], error) {
+	mock.mu.Lock()
+	mock.IterateCalls = append(mock.IterateCalls, MockUserHandlerIterateCall{
+		Ctx: ctx,
+	})
+	funcIterate := mock.IterateFunc
+	mock.mu.Unlock()
+
+	if funcIterate == nil {
+		panic("MockUserHandler.Iterate: IterateFunc is nil")
+	}
+
+	return funcIterate(ctx)
+}
+
+type MockUserHandlerIterateByNameCall struct {
+	Name string
+	F    func(*User) error
+}
+
+func (mock *MockUserHandler) IterateByName(name string, f func(*User) error) error {
+	mock.mu.Lock()
+	mock.IterateByNameCalls = append(mock.IterateByNameCalls, MockUserHandlerIterateByNameCall{
+		Name: name,
+		F:    f,
+	})
+	funcIterateByName := mock.IterateByNameFunc
+	mock.mu.Unlock()
+
+	if funcIterateByName == nil {
+		panic("MockUserHandler.IterateByName: IterateByNameFunc is nil")
+	}
+
+	return funcIterateByName(name, f)
+}
+
+type MockUserHandlerUpdateCall struct {
+	Ctx  context.Context
+	User *UserUpdate
+}
+
+func (mock *MockUserHandler) Update(ctx context.Context, user *UserUpdate) error {
+	mock.mu.Lock()
+	mock.UpdateCalls = append(mock.UpdateCalls, MockUserHandlerUpdateCall{
+		Ctx:  ctx,
+		User: user,
+	})
+	funcUpdate := mock.UpdateFunc
+	mock.mu.Unlock()
+
+	if funcUpdate == nil {
+		panic("MockUserHandler.Update: UpdateFunc is nil")
+	}
+
+	return funcUpdate(ctx, user)
+}
+
+type MockUserHandlerUpdateNameCall struct {
+	Ctx  context.Context
+	Id   int64
+	Name string
+}
+
+func (mock *MockUserHandler) UpdateName(ctx context.Context, id int64, name string) (sql.Result, error) {
+	mock.mu.Lock()
+	mock.UpdateNameCalls = append(mock.UpdateNameCalls, MockUserHandlerUpdateNameCall{
+		Ctx:  ctx,
+		Id:   id,
+		Name: name,
+	})
+	funcUpdateName := mock.UpdateNameFunc
+	mock.mu.Unlock()
+
+	if funcUpdateName == nil {
+		panic("MockUserHandler.UpdateName: UpdateNameFunc is nil")
+	}
+
+	return funcUpdateName(ctx, id, name)
+}
+
+type MockUserHandlerCreateUserCall struct {
+	Ctx  context.Context
+	Name string
+}
+
+func (mock *MockUserHandler) CreateUser(ctx context.Context, name string) (int64, error) {
+	mock.mu.Lock()
+	mock.CreateUserCalls = append(mock.CreateUserCalls, MockUserHandlerCreateUserCall{
+		Ctx:  ctx,
+		Name: name,
+	})
+	funcCreateUser := mock.CreateUserFunc
+	mock.mu.Unlock()
+
+	if funcCreateUser == nil {
+		panic("MockUserHandler.CreateUser: CreateUserFunc is nil")
+	}
+
+	return funcCreateUser(ctx, name)
+}
+
+type MockUserHandlerSwapNamesCall struct {
+	Ctx   context.Context
+	Name1 string
+	Id1   int64
+	Name2 string
+	Id2   int64
+}
+
+func (mock *MockUserHandler) SwapNames(ctx context.Context, name1 string, id1 int64, name2 string, id2 int64) (*mrpkg.Results, error) {
+	mock.mu.Lock()
+	mock.SwapNamesCalls = append(mock.SwapNamesCalls, MockUserHandlerSwapNamesCall{
+		Ctx:   ctx,
+		Name1: name1,
+		Id1:   id1,
+		Name2: name2,
+		Id2:   id2,
+	})
+	funcSwapNames := mock.SwapNamesFunc
+	mock.mu.Unlock()
+
+	if funcSwapNames == nil {
+		panic("MockUserHandler.SwapNames: SwapNamesFunc is nil")
+	}
+
+	return funcSwapNames(ctx, name1, id1, name2, id2)
+}
+
+type MockUserHandlerInsertUsersCall struct {
+	Ctx   context.Context
+	Users []*UserUpdate
+}
+
+func (mock *MockUserHandler) InsertUsers(ctx context.Context, users []*UserUpdate) (int64, error) {
+	mock.mu.Lock()
+	mock.InsertUsersCalls = append(mock.InsertUsersCalls, MockUserHandlerInsertUsersCall{
+		Ctx:   ctx,
+		Users: users,
+	})
+	funcInsertUsers := mock.InsertUsersFunc
+	mock.mu.Unlock()
+
+	if funcInsertUsers == nil {
+		panic("MockUserHandler.InsertUsers: InsertUsersFunc is nil")
+	}
+
+	return funcInsertUsers(ctx, users)
+}
+
+type MockUserHandlerWithTxCall struct {
+	Ctx  context.Context
+	Opts *sql.TxOptions
+}
+
+// WithTx invokes f with mock itself, as there is no transaction in memory
+func (mock *MockUserHandler) WithTx(ctx context.Context, opts *sql.TxOptions, f func(UserHandler) error) error {
+	mock.mu.Lock()
+	mock.WithTxCalls = append(mock.WithTxCalls, MockUserHandlerWithTxCall{
+		Ctx:  ctx,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+
+	return f(mock)
+}
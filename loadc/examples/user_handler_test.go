@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Boyux/mrpkg"
+)
+
+func TestUserHandlerRedactsQueryError(t *testing.T) {
+	const secret = "secret name"
+
+	handler := NewUserHandler("fakeError", "")
+
+	_, errCreate := handler.CreateUser(context.Background(), secret)
+	_, errUpdate := handler.UpdateName(context.Background(), 1, secret)
+
+	for _, err := range []error{errCreate, errUpdate} {
+		var queryErr *mrpkg.QueryError
+		if !errors.As(err, &queryErr) || !errors.Is(err, errFake) {
+			t.Fatalf("expect QueryError of %v, got %v", errFake, err)
+		}
+
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("%s: redacted value found in error: %s", queryErr.Caller, err)
+		}
+
+		if args := fmt.Sprint(queryErr.Args); strings.Contains(args, secret) || !strings.Contains(args, mrpkg.Redacted) {
+			t.Errorf("%s: expect redacted args, got %s", queryErr.Caller, args)
+		}
+	}
+}
+
+func TestUserHandlerClose(t *testing.T) {
+	handler := NewUserHandler("fake", "")
+
+	if _, err := handler.UpdateName(context.Background(), 1, "name"); err != nil {
+		t.Fatalf("UpdateName: %s", err)
+	}
+
+	closed := fakeStmtClosed.Load()
+	if err := handler.(io.Closer).Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	if fakeStmtClosed.Load() == closed {
+		t.Errorf("Close: expect cached statements closed")
+	}
+
+	if _, err := handler.UpdateName(context.Background(), 1, "name"); err != nil {
+		t.Errorf("UpdateName: expect statements prepared again after Close, got %s", err)
+	}
+}
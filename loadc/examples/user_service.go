@@ -6,11 +6,17 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Boyux/mrpkg"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/textproto"
+	"net/url"
+	"regexp"
+	"sync"
 	"text/template"
 	"time"
 )
@@ -76,41 +82,102 @@ func (imp implUserService) GetUser(ctx context.Context, id int64) (*User, error)
 	}
 
 	urlGetUser := addrGetUser.String()
-	requestGetUser, errGetUser := http.NewRequestWithContext(ctx, "GET", urlGetUser, http.NoBody)
-	if errGetUser != nil {
-		return v0GetUser, fmt.Errorf("error building 'GetUser' request: %w", errGetUser)
+
+	var (
+		retryMaxGetUser     int
+		retryBackoffGetUser func(int) time.Duration
+		retryableGetUser    func(*http.Response, error) bool
+		requestGetUser      *http.Request
+	)
+
+	if retrierGetUser, okGetUser := innerGetUser.(interface {
+		Retry() (int, func(int) time.Duration, func(*http.Response, error) bool)
+	}); okGetUser {
+		retryMaxGetUser, retryBackoffGetUser, retryableGetUser = retrierGetUser.Retry()
 	}
 
-	startGetUser := time.Now()
+	for attemptGetUser := 0; ; attemptGetUser++ {
 
-	if httpClientGetUser, okGetUser := innerGetUser.(interface{ Client() *http.Client }); okGetUser {
-		httpResponseGetUser, errGetUser = httpClientGetUser.Client().Do(requestGetUser)
-	} else {
-		httpResponseGetUser, errGetUser = http.DefaultClient.Do(requestGetUser)
+		requestGetUser, errGetUser = http.NewRequestWithContext(ctx, "GET", urlGetUser, http.NoBody)
+		if errGetUser != nil {
+			return v0GetUser, fmt.Errorf("error building 'GetUser' request: %w", errGetUser)
+		}
+
+		startGetUser := time.Now()
+
+		if httpClientGetUser, okGetUser := innerGetUser.(interface{ Client() *http.Client }); okGetUser {
+			httpResponseGetUser, errGetUser = httpClientGetUser.Client().Do(requestGetUser)
+		} else {
+			httpResponseGetUser, errGetUser = http.DefaultClient.Do(requestGetUser)
+		}
+
+		if logGetUser, okGetUser := innerGetUser.(interface {
+			Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+		}); okGetUser {
+			logGetUser.Log(ctx, "GetUser", "GET", urlGetUser, time.Since(startGetUser))
+		}
+
+		if attemptGetUser < retryMaxGetUser && retryableGetUser != nil && retryableGetUser(httpResponseGetUser, errGetUser) {
+			if errGetUser == nil {
+				io.Copy(io.Discard, httpResponseGetUser.Body)
+				httpResponseGetUser.Body.Close()
+			}
+
+			var delayGetUser time.Duration
+			if retryBackoffGetUser != nil {
+				delayGetUser = retryBackoffGetUser(attemptGetUser)
+			}
+
+			select {
+			case <-ctx.Done():
+				return v0GetUser, fmt.Errorf("error retrying 'GetUser' request: %w", ctx.Err())
+			case <-time.After(delayGetUser):
+			}
+
+			continue
+		}
+
+		if errGetUser != nil {
+			return v0GetUser, fmt.Errorf("error sending 'GetUser' request: %w", errGetUser)
+		}
+
+		break
 	}
 
-	if logGetUser, okGetUser := innerGetUser.(interface {
-		Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
-	}); okGetUser {
-		logGetUser.Log(ctx, "GetUser", "GET", urlGetUser, time.Since(startGetUser))
+	acceptedGetUser := httpResponseGetUser.StatusCode >= 200 && httpResponseGetUser.StatusCode <= 299
+	if acceptGetUser, okGetUser := responseGetUser.(interface{ Accept(int, http.Header) bool }); okGetUser {
+		acceptedGetUser = acceptGetUser.Accept(httpResponseGetUser.StatusCode, httpResponseGetUser.Header)
 	}
 
-	if errGetUser != nil {
-		return v0GetUser, fmt.Errorf("error sending 'GetUser' request: %w", errGetUser)
+	if !acceptedGetUser {
+		io.Copy(responseBodyGetUser, httpResponseGetUser.Body)
+		httpResponseGetUser.Body.Close()
+		return v0GetUser, &mrpkg.StatusError{
+			Caller:     "GetUser",
+			StatusCode: httpResponseGetUser.StatusCode,
+			Header:     httpResponseGetUser.Header,
+			Body:       append([]byte(nil), responseBodyGetUser.Bytes()...),
+		}
 	}
 
-	if _, errGetUser = io.Copy(responseBodyGetUser, httpResponseGetUser.Body); errGetUser != nil {
+	if fromResponseGetUser, okGetUser := responseGetUser.(interface {
+		FromResponse(string, *http.Response) error
+	}); okGetUser {
+		errGetUser = fromResponseGetUser.FromResponse("GetUser", httpResponseGetUser)
+		httpResponseGetUser.Body.Close()
+	} else if fromReaderGetUser, okGetUser := responseGetUser.(interface{ FromReader(string, io.Reader) error }); okGetUser {
+		errGetUser = fromReaderGetUser.FromReader("GetUser", httpResponseGetUser.Body)
 		httpResponseGetUser.Body.Close()
-		return v0GetUser, fmt.Errorf("error copying 'GetUser' response body: %w", errGetUser)
 	} else {
+		if _, errGetUser = io.Copy(responseBodyGetUser, httpResponseGetUser.Body); errGetUser != nil {
+			httpResponseGetUser.Body.Close()
+			return v0GetUser, fmt.Errorf("error copying 'GetUser' response body: %w", errGetUser)
+		}
 		httpResponseGetUser.Body.Close()
+		errGetUser = responseGetUser.FromBytes("GetUser", responseBodyGetUser.Bytes())
 	}
 
-	if httpResponseGetUser.StatusCode < 200 || httpResponseGetUser.StatusCode > 299 {
-		return v0GetUser, fmt.Errorf("response status code %d for 'GetUser' with body: \n\n%s\n\n", httpResponseGetUser.StatusCode, responseBodyGetUser.String())
-	}
-
-	if errGetUser = responseGetUser.FromBytes("GetUser", responseBodyGetUser.Bytes()); errGetUser != nil {
+	if errGetUser != nil {
 		return v0GetUser, fmt.Errorf("error converting 'GetUser' response: %w", errGetUser)
 	}
 
@@ -191,41 +258,98 @@ loop:
 		}
 
 		urlGetUsers := addrGetUsers.String()
-		requestGetUsers, errGetUsers := http.NewRequest("GET", urlGetUsers, http.NoBody)
-		if errGetUsers != nil {
-			return v0GetUsers, fmt.Errorf("error building 'GetUsers' request: %w", errGetUsers)
+
+		var (
+			retryMaxGetUsers     int
+			retryBackoffGetUsers func(int) time.Duration
+			retryableGetUsers    func(*http.Response, error) bool
+			requestGetUsers      *http.Request
+		)
+
+		if retrierGetUsers, okGetUsers := innerGetUsers.(interface {
+			Retry() (int, func(int) time.Duration, func(*http.Response, error) bool)
+		}); okGetUsers {
+			retryMaxGetUsers, retryBackoffGetUsers, retryableGetUsers = retrierGetUsers.Retry()
 		}
 
-		startGetUsers := time.Now()
+		for attemptGetUsers := 0; ; attemptGetUsers++ {
 
-		if httpClientGetUsers, okGetUsers := innerGetUsers.(interface{ Client() *http.Client }); okGetUsers {
-			httpResponseGetUsers, errGetUsers = httpClientGetUsers.Client().Do(requestGetUsers)
-		} else {
-			httpResponseGetUsers, errGetUsers = http.DefaultClient.Do(requestGetUsers)
+			requestGetUsers, errGetUsers = http.NewRequest("GET", urlGetUsers, http.NoBody)
+			if errGetUsers != nil {
+				return v0GetUsers, fmt.Errorf("error building 'GetUsers' request: %w", errGetUsers)
+			}
+
+			startGetUsers := time.Now()
+
+			if httpClientGetUsers, okGetUsers := innerGetUsers.(interface{ Client() *http.Client }); okGetUsers {
+				httpResponseGetUsers, errGetUsers = httpClientGetUsers.Client().Do(requestGetUsers)
+			} else {
+				httpResponseGetUsers, errGetUsers = http.DefaultClient.Do(requestGetUsers)
+			}
+
+			if logGetUsers, okGetUsers := innerGetUsers.(interface {
+				Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+			}); okGetUsers {
+				logGetUsers.Log(context.Background(), "GetUsers", "GET", urlGetUsers, time.Since(startGetUsers))
+			}
+
+			if attemptGetUsers < retryMaxGetUsers && retryableGetUsers != nil && retryableGetUsers(httpResponseGetUsers, errGetUsers) {
+				if errGetUsers == nil {
+					io.Copy(io.Discard, httpResponseGetUsers.Body)
+					httpResponseGetUsers.Body.Close()
+				}
+
+				var delayGetUsers time.Duration
+				if retryBackoffGetUsers != nil {
+					delayGetUsers = retryBackoffGetUsers(attemptGetUsers)
+				}
+
+				time.Sleep(delayGetUsers)
+
+				continue
+			}
+
+			if errGetUsers != nil {
+				return v0GetUsers, fmt.Errorf("error sending 'GetUsers' request: %w", errGetUsers)
+			}
+
+			break
 		}
 
-		if logGetUsers, okGetUsers := innerGetUsers.(interface {
-			Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
-		}); okGetUsers {
-			logGetUsers.Log(context.Background(), "GetUsers", "GET", urlGetUsers, time.Since(startGetUsers))
+		acceptedGetUsers := httpResponseGetUsers.StatusCode >= 200 && httpResponseGetUsers.StatusCode <= 299
+		if acceptGetUsers, okGetUsers := responseGetUsers.(interface{ Accept(int, http.Header) bool }); okGetUsers {
+			acceptedGetUsers = acceptGetUsers.Accept(httpResponseGetUsers.StatusCode, httpResponseGetUsers.Header)
 		}
 
-		if errGetUsers != nil {
-			return v0GetUsers, fmt.Errorf("error sending 'GetUsers' request: %w", errGetUsers)
+		if !acceptedGetUsers {
+			io.Copy(responseBodyGetUsers, httpResponseGetUsers.Body)
+			httpResponseGetUsers.Body.Close()
+			return v0GetUsers, &mrpkg.StatusError{
+				Caller:     "GetUsers",
+				StatusCode: httpResponseGetUsers.StatusCode,
+				Header:     httpResponseGetUsers.Header,
+				Body:       append([]byte(nil), responseBodyGetUsers.Bytes()...),
+			}
 		}
 
-		if _, errGetUsers = io.Copy(responseBodyGetUsers, httpResponseGetUsers.Body); errGetUsers != nil {
+		if fromResponseGetUsers, okGetUsers := responseGetUsers.(interface {
+			FromResponse(string, *http.Response) error
+		}); okGetUsers {
+			errGetUsers = fromResponseGetUsers.FromResponse("GetUsers", httpResponseGetUsers)
+			httpResponseGetUsers.Body.Close()
+		} else if fromReaderGetUsers, okGetUsers := responseGetUsers.(interface{ FromReader(string, io.Reader) error }); okGetUsers {
+			errGetUsers = fromReaderGetUsers.FromReader("GetUsers", httpResponseGetUsers.Body)
 			httpResponseGetUsers.Body.Close()
-			return v0GetUsers, fmt.Errorf("error copying 'GetUsers' response body: %w", errGetUsers)
 		} else {
+			if _, errGetUsers = io.Copy(responseBodyGetUsers, httpResponseGetUsers.Body); errGetUsers != nil {
+				httpResponseGetUsers.Body.Close()
+				return v0GetUsers, fmt.Errorf("error copying 'GetUsers' response body: %w", errGetUsers)
+			}
 			httpResponseGetUsers.Body.Close()
+			errGetUsers = responseGetUsers.FromBytes("GetUsers", responseBodyGetUsers.Bytes())
 		}
 
-		if httpResponseGetUsers.StatusCode < 200 || httpResponseGetUsers.StatusCode > 299 {
-			return v0GetUsers, fmt.Errorf("response status code %d for 'GetUsers' with body: \n\n%s\n\n", httpResponseGetUsers.StatusCode, responseBodyGetUsers.String())
-		}
-
-		if errGetUsers = responseGetUsers.FromBytes("GetUsers", responseBodyGetUsers.Bytes()); errGetUsers != nil {
+		if errGetUsers != nil {
 			return v0GetUsers, fmt.Errorf("error converting 'GetUsers' response: %w", errGetUsers)
 		}
 
@@ -311,54 +435,112 @@ func (imp implUserService) UpdateUser(user *User) error {
 		}); errUpdateUser != nil {
 		return fmt.Errorf("error building 'UpdateUser' header: %w", errUpdateUser)
 	}
-	bufReaderUpdateUser := bufio.NewReader(headerUpdateUser)
-	mimeHeaderUpdateUser, errUpdateUser := textproto.NewReader(bufReaderUpdateUser).ReadMIMEHeader()
-	if errUpdateUser != nil {
-		return fmt.Errorf("error reading 'UpdateUser' header: %w", errUpdateUser)
-	}
 
 	urlUpdateUser := addrUpdateUser.String()
-	requestUpdateUser, errUpdateUser := http.NewRequest("PUT", urlUpdateUser, bufReaderUpdateUser)
-	if errUpdateUser != nil {
-		return fmt.Errorf("error building 'UpdateUser' request: %w", errUpdateUser)
+
+	var (
+		retryMaxUpdateUser     int
+		retryBackoffUpdateUser func(int) time.Duration
+		retryableUpdateUser    func(*http.Response, error) bool
+		requestUpdateUser      *http.Request
+		mimeHeaderUpdateUser   textproto.MIMEHeader
+	)
+
+	if retrierUpdateUser, okUpdateUser := innerUpdateUser.(interface {
+		Retry() (int, func(int) time.Duration, func(*http.Response, error) bool)
+	}); okUpdateUser {
+		retryMaxUpdateUser, retryBackoffUpdateUser, retryableUpdateUser = retrierUpdateUser.Retry()
 	}
 
-	for kUpdateUser, vvUpdateUser := range mimeHeaderUpdateUser {
-		for _, vUpdateUser := range vvUpdateUser {
-			requestUpdateUser.Header.Add(kUpdateUser, vUpdateUser)
+	for attemptUpdateUser := 0; ; attemptUpdateUser++ {
+		bufReaderUpdateUser := bufio.NewReader(bytes.NewReader(headerUpdateUser.Bytes()))
+		mimeHeaderUpdateUser, errUpdateUser = textproto.NewReader(bufReaderUpdateUser).ReadMIMEHeader()
+		if errUpdateUser != nil {
+			return fmt.Errorf("error reading 'UpdateUser' header: %w", errUpdateUser)
 		}
-	}
 
-	startUpdateUser := time.Now()
+		requestUpdateUser, errUpdateUser = http.NewRequest("PUT", urlUpdateUser, bufReaderUpdateUser)
+		if errUpdateUser != nil {
+			return fmt.Errorf("error building 'UpdateUser' request: %w", errUpdateUser)
+		}
 
-	if httpClientUpdateUser, okUpdateUser := innerUpdateUser.(interface{ Client() *http.Client }); okUpdateUser {
-		httpResponseUpdateUser, errUpdateUser = httpClientUpdateUser.Client().Do(requestUpdateUser)
-	} else {
-		httpResponseUpdateUser, errUpdateUser = http.DefaultClient.Do(requestUpdateUser)
+		for kUpdateUser, vvUpdateUser := range mimeHeaderUpdateUser {
+			for _, vUpdateUser := range vvUpdateUser {
+				requestUpdateUser.Header.Add(kUpdateUser, vUpdateUser)
+			}
+		}
+
+		startUpdateUser := time.Now()
+
+		if httpClientUpdateUser, okUpdateUser := innerUpdateUser.(interface{ Client() *http.Client }); okUpdateUser {
+			httpResponseUpdateUser, errUpdateUser = httpClientUpdateUser.Client().Do(requestUpdateUser)
+		} else {
+			httpResponseUpdateUser, errUpdateUser = http.DefaultClient.Do(requestUpdateUser)
+		}
+
+		if logUpdateUser, okUpdateUser := innerUpdateUser.(interface {
+			Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+		}); okUpdateUser {
+			logUpdateUser.Log(context.Background(), "UpdateUser", "PUT", urlUpdateUser, time.Since(startUpdateUser))
+		}
+
+		if attemptUpdateUser < retryMaxUpdateUser && retryableUpdateUser != nil && retryableUpdateUser(httpResponseUpdateUser, errUpdateUser) {
+			if errUpdateUser == nil {
+				io.Copy(io.Discard, httpResponseUpdateUser.Body)
+				httpResponseUpdateUser.Body.Close()
+			}
+
+			var delayUpdateUser time.Duration
+			if retryBackoffUpdateUser != nil {
+				delayUpdateUser = retryBackoffUpdateUser(attemptUpdateUser)
+			}
+
+			time.Sleep(delayUpdateUser)
+
+			continue
+		}
+
+		if errUpdateUser != nil {
+			return fmt.Errorf("error sending 'UpdateUser' request: %w", errUpdateUser)
+		}
+
+		break
 	}
 
-	if logUpdateUser, okUpdateUser := innerUpdateUser.(interface {
-		Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
-	}); okUpdateUser {
-		logUpdateUser.Log(context.Background(), "UpdateUser", "PUT", urlUpdateUser, time.Since(startUpdateUser))
+	acceptedUpdateUser := httpResponseUpdateUser.StatusCode >= 200 && httpResponseUpdateUser.StatusCode <= 299
+	if acceptUpdateUser, okUpdateUser := responseUpdateUser.(interface{ Accept(int, http.Header) bool }); okUpdateUser {
+		acceptedUpdateUser = acceptUpdateUser.Accept(httpResponseUpdateUser.StatusCode, httpResponseUpdateUser.Header)
 	}
 
-	if errUpdateUser != nil {
-		return fmt.Errorf("error sending 'UpdateUser' request: %w", errUpdateUser)
+	if !acceptedUpdateUser {
+		io.Copy(responseBodyUpdateUser, httpResponseUpdateUser.Body)
+		httpResponseUpdateUser.Body.Close()
+		return &mrpkg.StatusError{
+			Caller:     "UpdateUser",
+			StatusCode: httpResponseUpdateUser.StatusCode,
+			Header:     httpResponseUpdateUser.Header,
+			Body:       append([]byte(nil), responseBodyUpdateUser.Bytes()...),
+		}
 	}
 
-	if _, errUpdateUser = io.Copy(responseBodyUpdateUser, httpResponseUpdateUser.Body); errUpdateUser != nil {
+	if fromResponseUpdateUser, okUpdateUser := responseUpdateUser.(interface {
+		FromResponse(string, *http.Response) error
+	}); okUpdateUser {
+		errUpdateUser = fromResponseUpdateUser.FromResponse("UpdateUser", httpResponseUpdateUser)
+		httpResponseUpdateUser.Body.Close()
+	} else if fromReaderUpdateUser, okUpdateUser := responseUpdateUser.(interface{ FromReader(string, io.Reader) error }); okUpdateUser {
+		errUpdateUser = fromReaderUpdateUser.FromReader("UpdateUser", httpResponseUpdateUser.Body)
 		httpResponseUpdateUser.Body.Close()
-		return fmt.Errorf("error copying 'UpdateUser' response body: %w", errUpdateUser)
 	} else {
+		if _, errUpdateUser = io.Copy(responseBodyUpdateUser, httpResponseUpdateUser.Body); errUpdateUser != nil {
+			httpResponseUpdateUser.Body.Close()
+			return fmt.Errorf("error copying 'UpdateUser' response body: %w", errUpdateUser)
+		}
 		httpResponseUpdateUser.Body.Close()
+		errUpdateUser = responseUpdateUser.FromBytes("UpdateUser", responseBodyUpdateUser.Bytes())
 	}
 
-	if httpResponseUpdateUser.StatusCode < 200 || httpResponseUpdateUser.StatusCode > 299 {
-		return fmt.Errorf("response status code %d for 'UpdateUser' with body: \n\n%s\n\n", httpResponseUpdateUser.StatusCode, responseBodyUpdateUser.String())
-	}
-
-	if errUpdateUser = responseUpdateUser.FromBytes("UpdateUser", responseBodyUpdateUser.Bytes()); errUpdateUser != nil {
+	if errUpdateUser != nil {
 		return fmt.Errorf("error converting 'UpdateUser' response: %w", errUpdateUser)
 	}
 
@@ -383,3 +565,1317 @@ func (imp implUserService) UpdateUser(user *User) error {
 
 	return nil
 }
+
+func (imp implUserService) PatchUser(ctx context.Context, id int64, name string) error {
+	var innerPatchUser any = imp.inner
+
+	if cachePatchUser, okPatchUser := innerPatchUser.(interface {
+		GetCache(string, ...any) []any
+	}); okPatchUser {
+		if cacheValuesPatchUser := cachePatchUser.GetCache("PatchUser", ctx, id, name); cacheValuesPatchUser != nil {
+			return nil
+		}
+	}
+
+	var (
+		addrTmplPatchUser   = template.New("AddressPatchUser")
+		headerTmplPatchUser = template.New("HeaderPatchUser")
+	)
+
+	addrPatchUser := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(addrPatchUser)
+	defer addrPatchUser.Reset()
+
+	headerPatchUser := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(headerPatchUser)
+	defer headerPatchUser.Reset()
+
+	responseBodyPatchUser := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(responseBodyPatchUser)
+	defer responseBodyPatchUser.Reset()
+
+	var (
+		errPatchUser          error
+		httpResponsePatchUser *http.Response
+		responsePatchUser     interface {
+			Err() error
+			ScanValues(...any) error
+			FromBytes(string, []byte) error
+			Break() bool
+		} = imp.Response()
+	)
+
+	if errPatchUser = template.Must(addrTmplPatchUser.Parse("{{ $.UserService.Host }}/user/{{ $.id }}")).
+		Execute(addrPatchUser, map[string]any{
+			"UserService": imp.inner,
+			"ctx":         ctx,
+			"id":          id,
+			"name":        name,
+		}); errPatchUser != nil {
+		return fmt.Errorf("error building 'PatchUser' url: %w", errPatchUser)
+	}
+
+	if errPatchUser = template.Must(headerTmplPatchUser.Parse("Content-Type: application/json\r\n\r\n{\r\n\"name\": {{ $.name }}\r\n}\r\n\r\n")).
+		Execute(headerPatchUser, map[string]any{
+			"UserService": imp.inner,
+			"ctx":         ctx,
+			"id":          id,
+			"name":        name,
+		}); errPatchUser != nil {
+		return fmt.Errorf("error building 'PatchUser' header: %w", errPatchUser)
+	}
+
+	urlPatchUser := addrPatchUser.String()
+	bufReaderPatchUser := bufio.NewReader(headerPatchUser)
+	mimeHeaderPatchUser, errPatchUser := textproto.NewReader(bufReaderPatchUser).ReadMIMEHeader()
+	if errPatchUser != nil {
+		return fmt.Errorf("error reading 'PatchUser' header: %w", errPatchUser)
+	}
+
+	requestPatchUser, errPatchUser := http.NewRequestWithContext(ctx, "PATCH", urlPatchUser, bufReaderPatchUser)
+	if errPatchUser != nil {
+		return fmt.Errorf("error building 'PatchUser' request: %w", errPatchUser)
+	}
+
+	for kPatchUser, vvPatchUser := range mimeHeaderPatchUser {
+		for _, vPatchUser := range vvPatchUser {
+			requestPatchUser.Header.Add(kPatchUser, vPatchUser)
+		}
+	}
+
+	startPatchUser := time.Now()
+
+	if httpClientPatchUser, okPatchUser := innerPatchUser.(interface{ Client() *http.Client }); okPatchUser {
+		httpResponsePatchUser, errPatchUser = httpClientPatchUser.Client().Do(requestPatchUser)
+	} else {
+		httpResponsePatchUser, errPatchUser = http.DefaultClient.Do(requestPatchUser)
+	}
+
+	if logPatchUser, okPatchUser := innerPatchUser.(interface {
+		Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+	}); okPatchUser {
+		logPatchUser.Log(ctx, "PatchUser", "PATCH", urlPatchUser, time.Since(startPatchUser))
+	}
+
+	if errPatchUser != nil {
+		return fmt.Errorf("error sending 'PatchUser' request: %w", errPatchUser)
+	}
+
+	acceptedPatchUser := httpResponsePatchUser.StatusCode >= 200 && httpResponsePatchUser.StatusCode <= 299
+	if acceptPatchUser, okPatchUser := responsePatchUser.(interface{ Accept(int, http.Header) bool }); okPatchUser {
+		acceptedPatchUser = acceptPatchUser.Accept(httpResponsePatchUser.StatusCode, httpResponsePatchUser.Header)
+	}
+
+	if !acceptedPatchUser {
+		io.Copy(responseBodyPatchUser, httpResponsePatchUser.Body)
+		httpResponsePatchUser.Body.Close()
+		return &mrpkg.StatusError{
+			Caller:     "PatchUser",
+			StatusCode: httpResponsePatchUser.StatusCode,
+			Header:     httpResponsePatchUser.Header,
+			Body:       append([]byte(nil), responseBodyPatchUser.Bytes()...),
+		}
+	}
+
+	if fromResponsePatchUser, okPatchUser := responsePatchUser.(interface {
+		FromResponse(string, *http.Response) error
+	}); okPatchUser {
+		errPatchUser = fromResponsePatchUser.FromResponse("PatchUser", httpResponsePatchUser)
+		httpResponsePatchUser.Body.Close()
+	} else if fromReaderPatchUser, okPatchUser := responsePatchUser.(interface{ FromReader(string, io.Reader) error }); okPatchUser {
+		errPatchUser = fromReaderPatchUser.FromReader("PatchUser", httpResponsePatchUser.Body)
+		httpResponsePatchUser.Body.Close()
+	} else {
+		if _, errPatchUser = io.Copy(responseBodyPatchUser, httpResponsePatchUser.Body); errPatchUser != nil {
+			httpResponsePatchUser.Body.Close()
+			return fmt.Errorf("error copying 'PatchUser' response body: %w", errPatchUser)
+		}
+		httpResponsePatchUser.Body.Close()
+		errPatchUser = responsePatchUser.FromBytes("PatchUser", responseBodyPatchUser.Bytes())
+	}
+
+	if errPatchUser != nil {
+		return fmt.Errorf("error converting 'PatchUser' response: %w", errPatchUser)
+	}
+
+	responseBodyPatchUser.Reset()
+
+	if errPatchUser = responsePatchUser.Err(); errPatchUser != nil {
+		return fmt.Errorf("error returned from 'PatchUser' response: %w", errPatchUser)
+	}
+
+	if errPatchUser = responsePatchUser.ScanValues(); errPatchUser != nil {
+		return fmt.Errorf("error scanning value from 'PatchUser' response: %w", errPatchUser)
+	}
+
+	if cachePatchUser, okPatchUser := innerPatchUser.(interface {
+		SetCache(string, []any, ...any)
+	}); okPatchUser {
+		cachePatchUser.SetCache(
+			"PatchUser",
+			[]any{ctx, id, name},
+		)
+	}
+
+	return nil
+}
+
+func (imp implUserService) Touch() error {
+	var innerTouch any = imp.inner
+
+	if cacheTouch, okTouch := innerTouch.(interface {
+		GetCache(string, ...any) []any
+	}); okTouch {
+		if cacheValuesTouch := cacheTouch.GetCache("Touch"); cacheValuesTouch != nil {
+			return nil
+		}
+	}
+
+	var (
+		addrTmplTouch = template.New("AddressTouch")
+	)
+
+	addrTouch := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(addrTouch)
+	defer addrTouch.Reset()
+
+	responseBodyTouch := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(responseBodyTouch)
+	defer responseBodyTouch.Reset()
+
+	var (
+		errTouch          error
+		httpResponseTouch *http.Response
+		responseTouch     interface {
+			Err() error
+			ScanValues(...any) error
+			FromBytes(string, []byte) error
+			Break() bool
+		} = imp.Response()
+	)
+
+	if errTouch = template.Must(addrTmplTouch.Parse("{{ $.UserService.Host }}/touch")).
+		Execute(addrTouch, map[string]any{
+			"UserService": imp.inner,
+		}); errTouch != nil {
+		return fmt.Errorf("error building 'Touch' url: %w", errTouch)
+	}
+
+	urlTouch := addrTouch.String()
+
+	var (
+		retryMaxTouch     int
+		retryBackoffTouch func(int) time.Duration
+		retryableTouch    func(*http.Response, error) bool
+		requestTouch      *http.Request
+	)
+
+	if retrierTouch, okTouch := innerTouch.(interface {
+		Retry() (int, func(int) time.Duration, func(*http.Response, error) bool)
+	}); okTouch {
+		retryMaxTouch, retryBackoffTouch, retryableTouch = retrierTouch.Retry()
+	}
+
+	for attemptTouch := 0; ; attemptTouch++ {
+
+		requestTouch, errTouch = http.NewRequest("PUT", urlTouch, http.NoBody)
+		if errTouch != nil {
+			return fmt.Errorf("error building 'Touch' request: %w", errTouch)
+		}
+
+		startTouch := time.Now()
+
+		if httpClientTouch, okTouch := innerTouch.(interface{ Client() *http.Client }); okTouch {
+			httpResponseTouch, errTouch = httpClientTouch.Client().Do(requestTouch)
+		} else {
+			httpResponseTouch, errTouch = http.DefaultClient.Do(requestTouch)
+		}
+
+		if logTouch, okTouch := innerTouch.(interface {
+			Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+		}); okTouch {
+			logTouch.Log(context.Background(), "Touch", "PUT", urlTouch, time.Since(startTouch))
+		}
+
+		if attemptTouch < retryMaxTouch && retryableTouch != nil && retryableTouch(httpResponseTouch, errTouch) {
+			if errTouch == nil {
+				io.Copy(io.Discard, httpResponseTouch.Body)
+				httpResponseTouch.Body.Close()
+			}
+
+			var delayTouch time.Duration
+			if retryBackoffTouch != nil {
+				delayTouch = retryBackoffTouch(attemptTouch)
+			}
+
+			time.Sleep(delayTouch)
+
+			continue
+		}
+
+		if errTouch != nil {
+			return fmt.Errorf("error sending 'Touch' request: %w", errTouch)
+		}
+
+		break
+	}
+
+	acceptedTouch := httpResponseTouch.StatusCode >= 200 && httpResponseTouch.StatusCode <= 299
+	if acceptTouch, okTouch := responseTouch.(interface{ Accept(int, http.Header) bool }); okTouch {
+		acceptedTouch = acceptTouch.Accept(httpResponseTouch.StatusCode, httpResponseTouch.Header)
+	}
+
+	if !acceptedTouch {
+		io.Copy(responseBodyTouch, httpResponseTouch.Body)
+		httpResponseTouch.Body.Close()
+		return &mrpkg.StatusError{
+			Caller:     "Touch",
+			StatusCode: httpResponseTouch.StatusCode,
+			Header:     httpResponseTouch.Header,
+			Body:       append([]byte(nil), responseBodyTouch.Bytes()...),
+		}
+	}
+
+	if fromResponseTouch, okTouch := responseTouch.(interface {
+		FromResponse(string, *http.Response) error
+	}); okTouch {
+		errTouch = fromResponseTouch.FromResponse("Touch", httpResponseTouch)
+		httpResponseTouch.Body.Close()
+	} else if fromReaderTouch, okTouch := responseTouch.(interface{ FromReader(string, io.Reader) error }); okTouch {
+		errTouch = fromReaderTouch.FromReader("Touch", httpResponseTouch.Body)
+		httpResponseTouch.Body.Close()
+	} else {
+		if _, errTouch = io.Copy(responseBodyTouch, httpResponseTouch.Body); errTouch != nil {
+			httpResponseTouch.Body.Close()
+			return fmt.Errorf("error copying 'Touch' response body: %w", errTouch)
+		}
+		httpResponseTouch.Body.Close()
+		errTouch = responseTouch.FromBytes("Touch", responseBodyTouch.Bytes())
+	}
+
+	if errTouch != nil {
+		return fmt.Errorf("error converting 'Touch' response: %w", errTouch)
+	}
+
+	responseBodyTouch.Reset()
+
+	if errTouch = responseTouch.Err(); errTouch != nil {
+		return fmt.Errorf("error returned from 'Touch' response: %w", errTouch)
+	}
+
+	if errTouch = responseTouch.ScanValues(); errTouch != nil {
+		return fmt.Errorf("error scanning value from 'Touch' response: %w", errTouch)
+	}
+
+	if cacheTouch, okTouch := innerTouch.(interface {
+		SetCache(string, []any, ...any)
+	}); okTouch {
+		cacheTouch.SetCache(
+			"Touch",
+			[]any{},
+		)
+	}
+
+	return nil
+}
+
+func (imp implUserService) DeleteUser(ctx context.Context, id int64) error {
+	var innerDeleteUser any = imp.inner
+
+	if cacheDeleteUser, okDeleteUser := innerDeleteUser.(interface {
+		GetCache(string, ...any) []any
+	}); okDeleteUser {
+		if cacheValuesDeleteUser := cacheDeleteUser.GetCache("DeleteUser", ctx, id); cacheValuesDeleteUser != nil {
+			return nil
+		}
+	}
+
+	var (
+		addrTmplDeleteUser = template.New("AddressDeleteUser")
+	)
+
+	addrDeleteUser := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(addrDeleteUser)
+	defer addrDeleteUser.Reset()
+
+	responseBodyDeleteUser := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(responseBodyDeleteUser)
+	defer responseBodyDeleteUser.Reset()
+
+	var (
+		errDeleteUser          error
+		httpResponseDeleteUser *http.Response
+		responseDeleteUser     interface {
+			Err() error
+			ScanValues(...any) error
+			FromBytes(string, []byte) error
+			Break() bool
+		} = imp.Response()
+	)
+
+	if errDeleteUser = template.Must(addrTmplDeleteUser.Parse("{{ $.UserService.Host }}/user/{{ $.id }}")).
+		Execute(addrDeleteUser, map[string]any{
+			"UserService": imp.inner,
+			"ctx":         ctx,
+			"id":          id,
+		}); errDeleteUser != nil {
+		return fmt.Errorf("error building 'DeleteUser' url: %w", errDeleteUser)
+	}
+
+	urlDeleteUser := addrDeleteUser.String()
+
+	var (
+		retryMaxDeleteUser     int
+		retryBackoffDeleteUser func(int) time.Duration
+		retryableDeleteUser    func(*http.Response, error) bool
+		requestDeleteUser      *http.Request
+	)
+
+	if retrierDeleteUser, okDeleteUser := innerDeleteUser.(interface {
+		Retry() (int, func(int) time.Duration, func(*http.Response, error) bool)
+	}); okDeleteUser {
+		retryMaxDeleteUser, retryBackoffDeleteUser, retryableDeleteUser = retrierDeleteUser.Retry()
+	}
+
+	for attemptDeleteUser := 0; ; attemptDeleteUser++ {
+
+		requestDeleteUser, errDeleteUser = http.NewRequestWithContext(ctx, "DELETE", urlDeleteUser, http.NoBody)
+		if errDeleteUser != nil {
+			return fmt.Errorf("error building 'DeleteUser' request: %w", errDeleteUser)
+		}
+
+		startDeleteUser := time.Now()
+
+		if httpClientDeleteUser, okDeleteUser := innerDeleteUser.(interface{ Client() *http.Client }); okDeleteUser {
+			httpResponseDeleteUser, errDeleteUser = httpClientDeleteUser.Client().Do(requestDeleteUser)
+		} else {
+			httpResponseDeleteUser, errDeleteUser = http.DefaultClient.Do(requestDeleteUser)
+		}
+
+		if logDeleteUser, okDeleteUser := innerDeleteUser.(interface {
+			Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+		}); okDeleteUser {
+			logDeleteUser.Log(ctx, "DeleteUser", "DELETE", urlDeleteUser, time.Since(startDeleteUser))
+		}
+
+		if attemptDeleteUser < retryMaxDeleteUser && retryableDeleteUser != nil && retryableDeleteUser(httpResponseDeleteUser, errDeleteUser) {
+			if errDeleteUser == nil {
+				io.Copy(io.Discard, httpResponseDeleteUser.Body)
+				httpResponseDeleteUser.Body.Close()
+			}
+
+			var delayDeleteUser time.Duration
+			if retryBackoffDeleteUser != nil {
+				delayDeleteUser = retryBackoffDeleteUser(attemptDeleteUser)
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("error retrying 'DeleteUser' request: %w", ctx.Err())
+			case <-time.After(delayDeleteUser):
+			}
+
+			continue
+		}
+
+		if errDeleteUser != nil {
+			return fmt.Errorf("error sending 'DeleteUser' request: %w", errDeleteUser)
+		}
+
+		break
+	}
+
+	acceptedDeleteUser := httpResponseDeleteUser.StatusCode >= 200 && httpResponseDeleteUser.StatusCode <= 299
+	if acceptDeleteUser, okDeleteUser := responseDeleteUser.(interface{ Accept(int, http.Header) bool }); okDeleteUser {
+		acceptedDeleteUser = acceptDeleteUser.Accept(httpResponseDeleteUser.StatusCode, httpResponseDeleteUser.Header)
+	}
+
+	if !acceptedDeleteUser {
+		io.Copy(responseBodyDeleteUser, httpResponseDeleteUser.Body)
+		httpResponseDeleteUser.Body.Close()
+		return &mrpkg.StatusError{
+			Caller:     "DeleteUser",
+			StatusCode: httpResponseDeleteUser.StatusCode,
+			Header:     httpResponseDeleteUser.Header,
+			Body:       append([]byte(nil), responseBodyDeleteUser.Bytes()...),
+		}
+	}
+
+	if fromResponseDeleteUser, okDeleteUser := responseDeleteUser.(interface {
+		FromResponse(string, *http.Response) error
+	}); okDeleteUser {
+		errDeleteUser = fromResponseDeleteUser.FromResponse("DeleteUser", httpResponseDeleteUser)
+		httpResponseDeleteUser.Body.Close()
+	} else if fromReaderDeleteUser, okDeleteUser := responseDeleteUser.(interface{ FromReader(string, io.Reader) error }); okDeleteUser {
+		errDeleteUser = fromReaderDeleteUser.FromReader("DeleteUser", httpResponseDeleteUser.Body)
+		httpResponseDeleteUser.Body.Close()
+	} else {
+		if _, errDeleteUser = io.Copy(responseBodyDeleteUser, httpResponseDeleteUser.Body); errDeleteUser != nil {
+			httpResponseDeleteUser.Body.Close()
+			return fmt.Errorf("error copying 'DeleteUser' response body: %w", errDeleteUser)
+		}
+		httpResponseDeleteUser.Body.Close()
+		errDeleteUser = responseDeleteUser.FromBytes("DeleteUser", responseBodyDeleteUser.Bytes())
+	}
+
+	if errDeleteUser != nil {
+		return fmt.Errorf("error converting 'DeleteUser' response: %w", errDeleteUser)
+	}
+
+	responseBodyDeleteUser.Reset()
+
+	if errDeleteUser = responseDeleteUser.Err(); errDeleteUser != nil {
+		return fmt.Errorf("error returned from 'DeleteUser' response: %w", errDeleteUser)
+	}
+
+	if errDeleteUser = responseDeleteUser.ScanValues(); errDeleteUser != nil {
+		return fmt.Errorf("error scanning value from 'DeleteUser' response: %w", errDeleteUser)
+	}
+
+	if cacheDeleteUser, okDeleteUser := innerDeleteUser.(interface {
+		SetCache(string, []any, ...any)
+	}); okDeleteUser {
+		cacheDeleteUser.SetCache(
+			"DeleteUser",
+			[]any{ctx, id},
+		)
+	}
+
+	return nil
+}
+
+func (imp implUserService) ExportUsers(ctx context.Context) (io.ReadCloser, error) {
+	var innerExportUsers any = imp.inner
+
+	var (
+		addrTmplExportUsers = template.New("AddressExportUsers")
+	)
+
+	addrExportUsers := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(addrExportUsers)
+	defer addrExportUsers.Reset()
+
+	responseBodyExportUsers := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(responseBodyExportUsers)
+	defer responseBodyExportUsers.Reset()
+
+	var (
+		v0ExportUsers           io.ReadCloser
+		errExportUsers          error
+		httpResponseExportUsers *http.Response
+		responseExportUsers     interface {
+			Err() error
+			ScanValues(...any) error
+			FromBytes(string, []byte) error
+			Break() bool
+		} = imp.Response()
+	)
+
+	if errExportUsers = template.Must(addrTmplExportUsers.Parse("{{ $.UserService.Host }}/users/export")).
+		Execute(addrExportUsers, map[string]any{
+			"UserService": imp.inner,
+			"ctx":         ctx,
+		}); errExportUsers != nil {
+		return v0ExportUsers, fmt.Errorf("error building 'ExportUsers' url: %w", errExportUsers)
+	}
+
+	urlExportUsers := addrExportUsers.String()
+
+	var (
+		retryMaxExportUsers     int
+		retryBackoffExportUsers func(int) time.Duration
+		retryableExportUsers    func(*http.Response, error) bool
+		requestExportUsers      *http.Request
+	)
+
+	if retrierExportUsers, okExportUsers := innerExportUsers.(interface {
+		Retry() (int, func(int) time.Duration, func(*http.Response, error) bool)
+	}); okExportUsers {
+		retryMaxExportUsers, retryBackoffExportUsers, retryableExportUsers = retrierExportUsers.Retry()
+	}
+
+	for attemptExportUsers := 0; ; attemptExportUsers++ {
+
+		requestExportUsers, errExportUsers = http.NewRequestWithContext(ctx, "GET", urlExportUsers, http.NoBody)
+		if errExportUsers != nil {
+			return v0ExportUsers, fmt.Errorf("error building 'ExportUsers' request: %w", errExportUsers)
+		}
+
+		startExportUsers := time.Now()
+
+		if httpClientExportUsers, okExportUsers := innerExportUsers.(interface{ Client() *http.Client }); okExportUsers {
+			httpResponseExportUsers, errExportUsers = httpClientExportUsers.Client().Do(requestExportUsers)
+		} else {
+			httpResponseExportUsers, errExportUsers = http.DefaultClient.Do(requestExportUsers)
+		}
+
+		if logExportUsers, okExportUsers := innerExportUsers.(interface {
+			Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+		}); okExportUsers {
+			logExportUsers.Log(ctx, "ExportUsers", "GET", urlExportUsers, time.Since(startExportUsers))
+		}
+
+		if attemptExportUsers < retryMaxExportUsers && retryableExportUsers != nil && retryableExportUsers(httpResponseExportUsers, errExportUsers) {
+			if errExportUsers == nil {
+				io.Copy(io.Discard, httpResponseExportUsers.Body)
+				httpResponseExportUsers.Body.Close()
+			}
+
+			var delayExportUsers time.Duration
+			if retryBackoffExportUsers != nil {
+				delayExportUsers = retryBackoffExportUsers(attemptExportUsers)
+			}
+
+			select {
+			case <-ctx.Done():
+				return v0ExportUsers, fmt.Errorf("error retrying 'ExportUsers' request: %w", ctx.Err())
+			case <-time.After(delayExportUsers):
+			}
+
+			continue
+		}
+
+		if errExportUsers != nil {
+			return v0ExportUsers, fmt.Errorf("error sending 'ExportUsers' request: %w", errExportUsers)
+		}
+
+		break
+	}
+
+	acceptedExportUsers := httpResponseExportUsers.StatusCode >= 200 && httpResponseExportUsers.StatusCode <= 299
+	if acceptExportUsers, okExportUsers := responseExportUsers.(interface{ Accept(int, http.Header) bool }); okExportUsers {
+		acceptedExportUsers = acceptExportUsers.Accept(httpResponseExportUsers.StatusCode, httpResponseExportUsers.Header)
+	}
+
+	if !acceptedExportUsers {
+		io.Copy(responseBodyExportUsers, httpResponseExportUsers.Body)
+		httpResponseExportUsers.Body.Close()
+		return v0ExportUsers, &mrpkg.StatusError{
+			Caller:     "ExportUsers",
+			StatusCode: httpResponseExportUsers.StatusCode,
+			Header:     httpResponseExportUsers.Header,
+			Body:       append([]byte(nil), responseBodyExportUsers.Bytes()...),
+		}
+	}
+
+	v0ExportUsers = httpResponseExportUsers.Body
+
+	return v0ExportUsers, nil
+}
+
+func (imp implUserService) UploadAvatar(ctx context.Context, id int64, avatar io.Reader) error {
+	var innerUploadAvatar any = imp.inner
+
+	if cacheUploadAvatar, okUploadAvatar := innerUploadAvatar.(interface {
+		GetCache(string, ...any) []any
+	}); okUploadAvatar {
+		if cacheValuesUploadAvatar := cacheUploadAvatar.GetCache("UploadAvatar", ctx, id, avatar); cacheValuesUploadAvatar != nil {
+			return nil
+		}
+	}
+
+	var (
+		addrTmplUploadAvatar   = template.New("AddressUploadAvatar")
+		headerTmplUploadAvatar = template.New("HeaderUploadAvatar")
+	)
+
+	addrUploadAvatar := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(addrUploadAvatar)
+	defer addrUploadAvatar.Reset()
+
+	headerUploadAvatar := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(headerUploadAvatar)
+	defer headerUploadAvatar.Reset()
+
+	responseBodyUploadAvatar := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(responseBodyUploadAvatar)
+	defer responseBodyUploadAvatar.Reset()
+
+	var (
+		errUploadAvatar          error
+		httpResponseUploadAvatar *http.Response
+		responseUploadAvatar     interface {
+			Err() error
+			ScanValues(...any) error
+			FromBytes(string, []byte) error
+			Break() bool
+		} = imp.Response()
+	)
+
+	if errUploadAvatar = template.Must(addrTmplUploadAvatar.Parse("{{ $.UserService.Host }}/user/{{ $.id }}/avatar")).
+		Execute(addrUploadAvatar, map[string]any{
+			"UserService": imp.inner,
+			"ctx":         ctx,
+			"id":          id,
+			"avatar":      avatar,
+		}); errUploadAvatar != nil {
+		return fmt.Errorf("error building 'UploadAvatar' url: %w", errUploadAvatar)
+	}
+
+	if errUploadAvatar = template.Must(headerTmplUploadAvatar.Parse("Content-Type: multipart/form-data\r\n\r\n")).
+		Execute(headerUploadAvatar, map[string]any{
+			"UserService": imp.inner,
+			"ctx":         ctx,
+			"id":          id,
+			"avatar":      avatar,
+		}); errUploadAvatar != nil {
+		return fmt.Errorf("error building 'UploadAvatar' header: %w", errUploadAvatar)
+	}
+
+	urlUploadAvatar := addrUploadAvatar.String()
+	bufReaderUploadAvatar := bufio.NewReader(headerUploadAvatar)
+	mimeHeaderUploadAvatar, errUploadAvatar := textproto.NewReader(bufReaderUploadAvatar).ReadMIMEHeader()
+	if errUploadAvatar != nil {
+		return fmt.Errorf("error reading 'UploadAvatar' header: %w", errUploadAvatar)
+	}
+
+	formContentTypeUploadAvatar, formBodyUploadAvatar := mrpkg.NewMultipartForm(
+		mrpkg.FormField{Name: "id", Value: id},
+		mrpkg.FormField{Name: "avatar", Value: avatar},
+	)
+	requestUploadAvatar, errUploadAvatar := http.NewRequestWithContext(ctx, "POST", urlUploadAvatar, formBodyUploadAvatar)
+	if errUploadAvatar != nil {
+		formBodyUploadAvatar.Close()
+		return fmt.Errorf("error building 'UploadAvatar' request: %w", errUploadAvatar)
+	}
+
+	for kUploadAvatar, vvUploadAvatar := range mimeHeaderUploadAvatar {
+		for _, vUploadAvatar := range vvUploadAvatar {
+			requestUploadAvatar.Header.Add(kUploadAvatar, vUploadAvatar)
+		}
+	}
+
+	requestUploadAvatar.Header.Set("Content-Type", formContentTypeUploadAvatar)
+
+	startUploadAvatar := time.Now()
+
+	if httpClientUploadAvatar, okUploadAvatar := innerUploadAvatar.(interface{ Client() *http.Client }); okUploadAvatar {
+		httpResponseUploadAvatar, errUploadAvatar = httpClientUploadAvatar.Client().Do(requestUploadAvatar)
+	} else {
+		httpResponseUploadAvatar, errUploadAvatar = http.DefaultClient.Do(requestUploadAvatar)
+	}
+
+	if logUploadAvatar, okUploadAvatar := innerUploadAvatar.(interface {
+		Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+	}); okUploadAvatar {
+		logUploadAvatar.Log(ctx, "UploadAvatar", "POST", urlUploadAvatar, time.Since(startUploadAvatar))
+	}
+
+	if errUploadAvatar != nil {
+		return fmt.Errorf("error sending 'UploadAvatar' request: %w", errUploadAvatar)
+	}
+
+	acceptedUploadAvatar := httpResponseUploadAvatar.StatusCode >= 200 && httpResponseUploadAvatar.StatusCode <= 299
+	if acceptUploadAvatar, okUploadAvatar := responseUploadAvatar.(interface{ Accept(int, http.Header) bool }); okUploadAvatar {
+		acceptedUploadAvatar = acceptUploadAvatar.Accept(httpResponseUploadAvatar.StatusCode, httpResponseUploadAvatar.Header)
+	}
+
+	if !acceptedUploadAvatar {
+		io.Copy(responseBodyUploadAvatar, httpResponseUploadAvatar.Body)
+		httpResponseUploadAvatar.Body.Close()
+		return &mrpkg.StatusError{
+			Caller:     "UploadAvatar",
+			StatusCode: httpResponseUploadAvatar.StatusCode,
+			Header:     httpResponseUploadAvatar.Header,
+			Body:       append([]byte(nil), responseBodyUploadAvatar.Bytes()...),
+		}
+	}
+
+	if fromResponseUploadAvatar, okUploadAvatar := responseUploadAvatar.(interface {
+		FromResponse(string, *http.Response) error
+	}); okUploadAvatar {
+		errUploadAvatar = fromResponseUploadAvatar.FromResponse("UploadAvatar", httpResponseUploadAvatar)
+		httpResponseUploadAvatar.Body.Close()
+	} else if fromReaderUploadAvatar, okUploadAvatar := responseUploadAvatar.(interface{ FromReader(string, io.Reader) error }); okUploadAvatar {
+		errUploadAvatar = fromReaderUploadAvatar.FromReader("UploadAvatar", httpResponseUploadAvatar.Body)
+		httpResponseUploadAvatar.Body.Close()
+	} else {
+		if _, errUploadAvatar = io.Copy(responseBodyUploadAvatar, httpResponseUploadAvatar.Body); errUploadAvatar != nil {
+			httpResponseUploadAvatar.Body.Close()
+			return fmt.Errorf("error copying 'UploadAvatar' response body: %w", errUploadAvatar)
+		}
+		httpResponseUploadAvatar.Body.Close()
+		errUploadAvatar = responseUploadAvatar.FromBytes("UploadAvatar", responseBodyUploadAvatar.Bytes())
+	}
+
+	if errUploadAvatar != nil {
+		return fmt.Errorf("error converting 'UploadAvatar' response: %w", errUploadAvatar)
+	}
+
+	responseBodyUploadAvatar.Reset()
+
+	if errUploadAvatar = responseUploadAvatar.Err(); errUploadAvatar != nil {
+		return fmt.Errorf("error returned from 'UploadAvatar' response: %w", errUploadAvatar)
+	}
+
+	if errUploadAvatar = responseUploadAvatar.ScanValues(); errUploadAvatar != nil {
+		return fmt.Errorf("error scanning value from 'UploadAvatar' response: %w", errUploadAvatar)
+	}
+
+	if cacheUploadAvatar, okUploadAvatar := innerUploadAvatar.(interface {
+		SetCache(string, []any, ...any)
+	}); okUploadAvatar {
+		cacheUploadAvatar.SetCache(
+			"UploadAvatar",
+			[]any{ctx, id, avatar},
+		)
+	}
+
+	return nil
+}
+
+func (imp implUserService) SearchUsers(name string, tags []string) ([]User, error) {
+	var innerSearchUsers any = imp.inner
+
+	if cacheSearchUsers, okSearchUsers := innerSearchUsers.(interface {
+		GetCache(string, ...any) []any
+	}); okSearchUsers {
+		if cacheValuesSearchUsers := cacheSearchUsers.GetCache("SearchUsers", name, tags); cacheValuesSearchUsers != nil {
+			return cacheValuesSearchUsers[0].([]User), nil
+		}
+	}
+
+	var (
+		valuesSearchUsers = make([]User, 0, 10)
+		nSearchUsers      = 0
+		pageSearchUsers   = func() int {
+			current := nSearchUsers
+			nSearchUsers++
+			return current
+		}
+		addrTmplSearchUsers = template.New("AddressSearchUsers").Funcs(template.FuncMap{
+			"page": pageSearchUsers,
+		})
+		headerTmplSearchUsers = template.New("HeaderSearchUsers").Funcs(template.FuncMap{
+			"page": pageSearchUsers,
+		})
+	)
+
+	addrSearchUsers := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(addrSearchUsers)
+	defer addrSearchUsers.Reset()
+
+	headerSearchUsers := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(headerSearchUsers)
+	defer headerSearchUsers.Reset()
+
+	responseBodySearchUsers := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(responseBodySearchUsers)
+	defer responseBodySearchUsers.Reset()
+
+loop:
+	for {
+		var (
+			v0SearchUsers           []User
+			errSearchUsers          error
+			httpResponseSearchUsers *http.Response
+			responseSearchUsers     interface {
+				Err() error
+				ScanValues(...any) error
+				FromBytes(string, []byte) error
+				Break() bool
+			} = imp.Response()
+		)
+
+		if errSearchUsers = template.Must(addrTmplSearchUsers.Parse("{{ $.UserService.Host }}/users/search")).
+			Execute(addrSearchUsers, map[string]any{
+				"UserService": imp.inner,
+				"name":        name,
+				"tags":        tags,
+			}); errSearchUsers != nil {
+			return v0SearchUsers, fmt.Errorf("error building 'SearchUsers' url: %w", errSearchUsers)
+		}
+
+		if errSearchUsers = template.Must(headerTmplSearchUsers.Parse("Content-Type: application/x-www-form-urlencoded\r\n\r\n")).
+			Execute(headerSearchUsers, map[string]any{
+				"UserService": imp.inner,
+				"name":        name,
+				"tags":        tags,
+			}); errSearchUsers != nil {
+			return v0SearchUsers, fmt.Errorf("error building 'SearchUsers' header: %w", errSearchUsers)
+		}
+
+		urlSearchUsers := addrSearchUsers.String()
+
+		formValuesSearchUsers, errSearchUsers := mrpkg.EncodeForm(
+			mrpkg.FormField{Name: "name", Value: name},
+			mrpkg.FormField{Name: "tags", Value: tags},
+		)
+		if errSearchUsers != nil {
+			return v0SearchUsers, fmt.Errorf("error encoding 'SearchUsers' form: %w", errSearchUsers)
+		}
+
+		bufReaderSearchUsers := bufio.NewReader(headerSearchUsers)
+		mimeHeaderSearchUsers, errSearchUsers := textproto.NewReader(bufReaderSearchUsers).ReadMIMEHeader()
+		if errSearchUsers != nil {
+			return v0SearchUsers, fmt.Errorf("error reading 'SearchUsers' header: %w", errSearchUsers)
+		}
+
+		requestSearchUsers, errSearchUsers := http.NewRequest("POST", urlSearchUsers, bytes.NewBufferString(formValuesSearchUsers.Encode()))
+		if errSearchUsers != nil {
+			return v0SearchUsers, fmt.Errorf("error building 'SearchUsers' request: %w", errSearchUsers)
+		}
+
+		for kSearchUsers, vvSearchUsers := range mimeHeaderSearchUsers {
+			for _, vSearchUsers := range vvSearchUsers {
+				requestSearchUsers.Header.Add(kSearchUsers, vSearchUsers)
+			}
+		}
+
+		startSearchUsers := time.Now()
+
+		if httpClientSearchUsers, okSearchUsers := innerSearchUsers.(interface{ Client() *http.Client }); okSearchUsers {
+			httpResponseSearchUsers, errSearchUsers = httpClientSearchUsers.Client().Do(requestSearchUsers)
+		} else {
+			httpResponseSearchUsers, errSearchUsers = http.DefaultClient.Do(requestSearchUsers)
+		}
+
+		if logSearchUsers, okSearchUsers := innerSearchUsers.(interface {
+			Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+		}); okSearchUsers {
+			logSearchUsers.Log(context.Background(), "SearchUsers", "POST", urlSearchUsers, time.Since(startSearchUsers))
+		}
+
+		if errSearchUsers != nil {
+			return v0SearchUsers, fmt.Errorf("error sending 'SearchUsers' request: %w", errSearchUsers)
+		}
+
+		acceptedSearchUsers := httpResponseSearchUsers.StatusCode >= 200 && httpResponseSearchUsers.StatusCode <= 299
+		if acceptSearchUsers, okSearchUsers := responseSearchUsers.(interface{ Accept(int, http.Header) bool }); okSearchUsers {
+			acceptedSearchUsers = acceptSearchUsers.Accept(httpResponseSearchUsers.StatusCode, httpResponseSearchUsers.Header)
+		}
+
+		if !acceptedSearchUsers {
+			io.Copy(responseBodySearchUsers, httpResponseSearchUsers.Body)
+			httpResponseSearchUsers.Body.Close()
+			return v0SearchUsers, &mrpkg.StatusError{
+				Caller:     "SearchUsers",
+				StatusCode: httpResponseSearchUsers.StatusCode,
+				Header:     httpResponseSearchUsers.Header,
+				Body:       append([]byte(nil), responseBodySearchUsers.Bytes()...),
+			}
+		}
+
+		if fromResponseSearchUsers, okSearchUsers := responseSearchUsers.(interface {
+			FromResponse(string, *http.Response) error
+		}); okSearchUsers {
+			errSearchUsers = fromResponseSearchUsers.FromResponse("SearchUsers", httpResponseSearchUsers)
+			httpResponseSearchUsers.Body.Close()
+		} else if fromReaderSearchUsers, okSearchUsers := responseSearchUsers.(interface{ FromReader(string, io.Reader) error }); okSearchUsers {
+			errSearchUsers = fromReaderSearchUsers.FromReader("SearchUsers", httpResponseSearchUsers.Body)
+			httpResponseSearchUsers.Body.Close()
+		} else {
+			if _, errSearchUsers = io.Copy(responseBodySearchUsers, httpResponseSearchUsers.Body); errSearchUsers != nil {
+				httpResponseSearchUsers.Body.Close()
+				return v0SearchUsers, fmt.Errorf("error copying 'SearchUsers' response body: %w", errSearchUsers)
+			}
+			httpResponseSearchUsers.Body.Close()
+			errSearchUsers = responseSearchUsers.FromBytes("SearchUsers", responseBodySearchUsers.Bytes())
+		}
+
+		if errSearchUsers != nil {
+			return v0SearchUsers, fmt.Errorf("error converting 'SearchUsers' response: %w", errSearchUsers)
+		}
+
+		responseBodySearchUsers.Reset()
+
+		if errSearchUsers = responseSearchUsers.Err(); errSearchUsers != nil {
+			return v0SearchUsers, fmt.Errorf("error returned from 'SearchUsers' response: %w", errSearchUsers)
+		}
+
+		if errSearchUsers = responseSearchUsers.ScanValues(&v0SearchUsers); errSearchUsers != nil {
+			return v0SearchUsers, fmt.Errorf("error scanning value from 'SearchUsers' response: %w", errSearchUsers)
+		}
+
+		valuesSearchUsers = append(valuesSearchUsers, v0SearchUsers...)
+		if responseSearchUsers.Break() {
+			break loop
+		}
+	}
+
+	if cacheSearchUsers, okSearchUsers := innerSearchUsers.(interface {
+		SetCache(string, []any, ...any)
+	}); okSearchUsers {
+		cacheSearchUsers.SetCache(
+			"SearchUsers",
+			[]any{name, tags},
+			valuesSearchUsers)
+	}
+
+	return valuesSearchUsers, nil
+}
+
+func (imp implUserService) HasUser(ctx context.Context, id int64) error {
+	var innerHasUser any = imp.inner
+
+	if cacheHasUser, okHasUser := innerHasUser.(interface {
+		GetCache(string, ...any) []any
+	}); okHasUser {
+		if cacheValuesHasUser := cacheHasUser.GetCache("HasUser", ctx, id); cacheValuesHasUser != nil {
+			return nil
+		}
+	}
+
+	var (
+		addrTmplHasUser = template.New("AddressHasUser")
+	)
+
+	addrHasUser := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(addrHasUser)
+	defer addrHasUser.Reset()
+
+	responseBodyHasUser := mrpkg.GetObj[*bytes.Buffer]()
+	defer mrpkg.PutObj(responseBodyHasUser)
+	defer responseBodyHasUser.Reset()
+
+	var (
+		errHasUser          error
+		httpResponseHasUser *http.Response
+	)
+
+	if errHasUser = template.Must(addrTmplHasUser.Parse("{{ $.UserService.Host }}/user/{{ $.id }}")).
+		Execute(addrHasUser, map[string]any{
+			"UserService": imp.inner,
+			"ctx":         ctx,
+			"id":          id,
+		}); errHasUser != nil {
+		return fmt.Errorf("error building 'HasUser' url: %w", errHasUser)
+	}
+
+	urlHasUser := addrHasUser.String()
+
+	var (
+		retryMaxHasUser     int
+		retryBackoffHasUser func(int) time.Duration
+		retryableHasUser    func(*http.Response, error) bool
+		requestHasUser      *http.Request
+	)
+
+	if retrierHasUser, okHasUser := innerHasUser.(interface {
+		Retry() (int, func(int) time.Duration, func(*http.Response, error) bool)
+	}); okHasUser {
+		retryMaxHasUser, retryBackoffHasUser, retryableHasUser = retrierHasUser.Retry()
+	}
+
+	for attemptHasUser := 0; ; attemptHasUser++ {
+
+		requestHasUser, errHasUser = http.NewRequestWithContext(ctx, "HEAD", urlHasUser, http.NoBody)
+		if errHasUser != nil {
+			return fmt.Errorf("error building 'HasUser' request: %w", errHasUser)
+		}
+
+		startHasUser := time.Now()
+
+		if httpClientHasUser, okHasUser := innerHasUser.(interface{ Client() *http.Client }); okHasUser {
+			httpResponseHasUser, errHasUser = httpClientHasUser.Client().Do(requestHasUser)
+		} else {
+			httpResponseHasUser, errHasUser = http.DefaultClient.Do(requestHasUser)
+		}
+
+		if logHasUser, okHasUser := innerHasUser.(interface {
+			Log(ctx context.Context, caller string, method string, url string, elapse time.Duration)
+		}); okHasUser {
+			logHasUser.Log(ctx, "HasUser", "HEAD", urlHasUser, time.Since(startHasUser))
+		}
+
+		if attemptHasUser < retryMaxHasUser && retryableHasUser != nil && retryableHasUser(httpResponseHasUser, errHasUser) {
+			if errHasUser == nil {
+				io.Copy(io.Discard, httpResponseHasUser.Body)
+				httpResponseHasUser.Body.Close()
+			}
+
+			var delayHasUser time.Duration
+			if retryBackoffHasUser != nil {
+				delayHasUser = retryBackoffHasUser(attemptHasUser)
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("error retrying 'HasUser' request: %w", ctx.Err())
+			case <-time.After(delayHasUser):
+			}
+
+			continue
+		}
+
+		if errHasUser != nil {
+			return fmt.Errorf("error sending 'HasUser' request: %w", errHasUser)
+		}
+
+		break
+	}
+
+	httpResponseHasUser.Body.Close()
+
+	acceptedHasUser := httpResponseHasUser.StatusCode >= 200 && httpResponseHasUser.StatusCode <= 299
+	if acceptHasUser, okHasUser := any(imp.Response()).(interface{ Accept(int, http.Header) bool }); okHasUser {
+		acceptedHasUser = acceptHasUser.Accept(httpResponseHasUser.StatusCode, httpResponseHasUser.Header)
+	}
+
+	if !acceptedHasUser {
+		return &mrpkg.StatusError{
+			Caller:     "HasUser",
+			StatusCode: httpResponseHasUser.StatusCode,
+			Header:     httpResponseHasUser.Header,
+		}
+	}
+
+	if cacheHasUser, okHasUser := innerHasUser.(interface {
+		SetCache(string, []any, ...any)
+	}); okHasUser {
+		cacheHasUser.SetCache(
+			"HasUser",
+			[]any{ctx, id},
+		)
+	}
+
+	return nil
+}
+
+// MockUserServiceServer is an httptest server serving routes declared in UserService for tests,
+// each route calls its '<Method>Func' field and records the received request into
+// 'Requests' field, point the host of UserService to 'URL' field to make use of it
+type MockUserServiceServer struct {
+	*httptest.Server
+
+	mu sync.Mutex
+
+	GetUserFunc      func(r *http.Request) (*User, error)
+	GetUsersFunc     func(r *http.Request) ([]User, error)
+	UpdateUserFunc   func(r *http.Request) error
+	PatchUserFunc    func(r *http.Request) error
+	TouchFunc        func(r *http.Request) error
+	DeleteUserFunc   func(r *http.Request) error
+	ExportUsersFunc  func(r *http.Request) (io.ReadCloser, error)
+	UploadAvatarFunc func(r *http.Request) error
+	SearchUsersFunc  func(r *http.Request) ([]User, error)
+	HasUserFunc      func(r *http.Request) error
+
+	// Encode writes value returned from '<Method>Func' into response, value is encoded
+	// as JSON if Encode is nil, unless it is an 'io.Reader' which is copied as is
+	Encode func(w http.ResponseWriter, r *http.Request, value any) error
+
+	Requests []MockUserServiceRequest
+}
+
+type MockUserServiceRequest struct {
+	Caller string
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+var mockUserServiceRoutes = []struct {
+	caller  string
+	method  string
+	pattern *regexp.Regexp
+}{
+	{"GetUser", "GET", regexp.MustCompile(`^/user/[^/]*$`)},
+	{"GetUsers", "GET", regexp.MustCompile(`^/users$`)},
+	{"UpdateUser", "PUT", regexp.MustCompile(`^/user$`)},
+	{"PatchUser", "PATCH", regexp.MustCompile(`^/user/[^/]*$`)},
+	{"Touch", "PUT", regexp.MustCompile(`^/touch$`)},
+	{"DeleteUser", "DELETE", regexp.MustCompile(`^/user/[^/]*$`)},
+	{"ExportUsers", "GET", regexp.MustCompile(`^/users/export$`)},
+	{"UploadAvatar", "POST", regexp.MustCompile(`^/user/[^/]*/avatar$`)},
+	{"SearchUsers", "POST", regexp.MustCompile(`^/users/search$`)},
+	{"HasUser", "HEAD", regexp.MustCompile(`^/user/[^/]*$`)},
+}
+
+// NewMockUserServiceServer starts and returns a new MockUserServiceServer, caller should call
+// its Close method when finished
+func NewMockUserServiceServer() *MockUserServiceServer {
+	mock := &MockUserServiceServer{}
+	mock.Server = httptest.NewServer(mock)
+	return mock
+}
+
+func (mock *MockUserServiceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var caller string
+	for _, route := range mockUserServiceRoutes {
+		if route.method == r.Method && route.pattern.MatchString(r.URL.Path) {
+			caller = route.caller
+			break
+		}
+	}
+
+	if caller == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	mock.mu.Lock()
+	mock.Requests = append(mock.Requests, MockUserServiceRequest{
+		Caller: caller,
+		Method: r.Method,
+		URL:    r.URL,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	encode := mock.Encode
+	mock.mu.Unlock()
+
+	var value any
+	switch caller {
+	case "GetUser":
+		mock.mu.Lock()
+		funcGetUser := mock.GetUserFunc
+		mock.mu.Unlock()
+
+		if funcGetUser == nil {
+			http.Error(w, "MockUserServiceServer.GetUser: GetUserFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		value, err = funcGetUser(r)
+	case "GetUsers":
+		mock.mu.Lock()
+		funcGetUsers := mock.GetUsersFunc
+		mock.mu.Unlock()
+
+		if funcGetUsers == nil {
+			http.Error(w, "MockUserServiceServer.GetUsers: GetUsersFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		value, err = funcGetUsers(r)
+	case "UpdateUser":
+		mock.mu.Lock()
+		funcUpdateUser := mock.UpdateUserFunc
+		mock.mu.Unlock()
+
+		if funcUpdateUser == nil {
+			http.Error(w, "MockUserServiceServer.UpdateUser: UpdateUserFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		err = funcUpdateUser(r)
+	case "PatchUser":
+		mock.mu.Lock()
+		funcPatchUser := mock.PatchUserFunc
+		mock.mu.Unlock()
+
+		if funcPatchUser == nil {
+			http.Error(w, "MockUserServiceServer.PatchUser: PatchUserFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		err = funcPatchUser(r)
+	case "Touch":
+		mock.mu.Lock()
+		funcTouch := mock.TouchFunc
+		mock.mu.Unlock()
+
+		if funcTouch == nil {
+			http.Error(w, "MockUserServiceServer.Touch: TouchFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		err = funcTouch(r)
+	case "DeleteUser":
+		mock.mu.Lock()
+		funcDeleteUser := mock.DeleteUserFunc
+		mock.mu.Unlock()
+
+		if funcDeleteUser == nil {
+			http.Error(w, "MockUserServiceServer.DeleteUser: DeleteUserFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		err = funcDeleteUser(r)
+	case "ExportUsers":
+		mock.mu.Lock()
+		funcExportUsers := mock.ExportUsersFunc
+		mock.mu.Unlock()
+
+		if funcExportUsers == nil {
+			http.Error(w, "MockUserServiceServer.ExportUsers: ExportUsersFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		value, err = funcExportUsers(r)
+	case "UploadAvatar":
+		mock.mu.Lock()
+		funcUploadAvatar := mock.UploadAvatarFunc
+		mock.mu.Unlock()
+
+		if funcUploadAvatar == nil {
+			http.Error(w, "MockUserServiceServer.UploadAvatar: UploadAvatarFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		err = funcUploadAvatar(r)
+	case "SearchUsers":
+		mock.mu.Lock()
+		funcSearchUsers := mock.SearchUsersFunc
+		mock.mu.Unlock()
+
+		if funcSearchUsers == nil {
+			http.Error(w, "MockUserServiceServer.SearchUsers: SearchUsersFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		value, err = funcSearchUsers(r)
+	case "HasUser":
+		mock.mu.Lock()
+		funcHasUser := mock.HasUserFunc
+		mock.mu.Unlock()
+
+		if funcHasUser == nil {
+			http.Error(w, "MockUserServiceServer.HasUser: HasUserFunc is nil", http.StatusNotImplemented)
+			return
+		}
+
+		err = funcHasUser(r)
+	}
+
+	if err != nil {
+		var statusErr *mrpkg.StatusError
+		if errors.As(err, &statusErr) {
+			for k, vv := range statusErr.Header {
+				for _, v := range vv {
+					w.Header().Add(k, v)
+				}
+			}
+			w.WriteHeader(statusErr.StatusCode)
+			w.Write(statusErr.Body)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if value == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if encode == nil {
+		encode = mock.encode
+	}
+
+	if err = encode(w, r, value); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (mock *MockUserServiceServer) encode(w http.ResponseWriter, _ *http.Request, value any) error {
+	if reader, ok := value.(io.Reader); ok {
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+		_, err := io.Copy(w, reader)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go/ast"
+	"go/token"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	CmdGenerate = "generate"
+
+	MarkerPrefix = "//loadc:"
+)
+
+// Marker represents a '//loadc:<mode> [--features=...] [--output=...]' comment
+// attached to an interface type declaration, which works the same as a
+// 'go:generate' line running loadc with identical args, 'loadc check' also
+// takes 'go:generate' lines as Marker
+type Marker struct {
+	Mode     string
+	Features []string
+	Output   string
+	Schema   string
+	Timeout  time.Duration
+
+	// Pointer and Args are only available with 'go:generate' lines in
+	// '--mode=sql', markers do not support '--mode=sql'
+	Pointer bool
+	Args    []string
+
+	// File and Line locate the interface type declaration, Line is the line
+	// number of the type name rather than the marker comment (or the line
+	// next to 'go:generate' comment)
+	File  string
+	Line  int
+	Ident string
+}
+
+var generate = &cobra.Command{
+	Use:   CmdGenerate + " [dir]",
+	Short: "generate implementations for all interfaces marked with '//loadc:sqlx' or '//loadc:api' in package",
+	Long: "" +
+		"generate loads the whole package in dir (current directory by default), finds every interface\n" +
+		"marked with '//loadc:sqlx' or '//loadc:api' comment and generates its implementation into\n" +
+		"'--output' file (snake_case of interface name by default), outputs that are already up to date\n" +
+		"are left untouched.",
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          genPackage,
+}
+
+func genPackage(_ *cobra.Command, args []string) error {
+	dir := CurrentDir
+	if len(args) > 0 {
+		dir = args[0]
+		if !isAbs(dir) {
+			dir = join(CurrentDir, dir)
+		}
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+
+	pkgName, markers, err := inspectPackage(dir)
+	if err != nil {
+		return fmt.Errorf("inspectPackage(%s): %w", quote(dir), err)
+	}
+
+	outputs := make(map[string]string, len(markers))
+	for _, marker := range markers {
+		if ident, exists := outputs[marker.Output]; exists {
+			return fmt.Errorf("%s:%d: output %s of %s conflicts with %s",
+				marker.File,
+				marker.Line,
+				quote(marker.Output),
+				quote(marker.Ident),
+				quote(ident))
+		}
+		outputs[marker.Output] = marker.Ident
+	}
+
+	for _, marker := range markers {
+		code, err := buildMarker(pkgName, dir, marker)
+		if err != nil {
+			return fmt.Errorf("generate %s: %w", quote(marker.Ident), err)
+		}
+
+		path := join(dir, marker.Output)
+		if current, err := read(path); err == nil && bytes.Equal(current, code) {
+			continue
+		}
+
+		if err = write(path, code, FileMode); err != nil {
+			return fmt.Errorf("os.WriteFile(%s, %04x): %w", path, FileMode, err)
+		}
+	}
+
+	return nil
+}
+
+// buildMarker prepares global states which are set from environments in
+// 'go:generate' mode, then builds code for interface located by marker
+func buildMarker(pkgName string, dir string, marker *Marker) (code []byte, err error) {
+	if FileContent, err = read(marker.File); err != nil {
+		return nil, fmt.Errorf("os.ReadFile(%s): %w", quote(marker.File), err)
+	}
+
+	PackageName = pkgName
+	CurrentDir = dir
+	CurrentFile = base(marker.File)
+	LineNum = marker.Line - 1
+	features = marker.Features
+	output = marker.Output
+	pointer = marker.Pointer
+	schema = marker.Schema
+	timeout = marker.Timeout
+
+	switch marker.Mode {
+	case ModeSql:
+		return buildSql(marker.Args)
+	case ModeSqlx:
+		return buildSqlx()
+	case ModeApi:
+		return buildApi()
+	default:
+		return nil, fmt.Errorf("unsupported mode %s", quote(marker.Mode))
+	}
+}
+
+func inspectPackage(dir string) (string, []*Marker, error) {
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fset := pkg.Fset
+	var markers []*Marker
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if _, ok := typeSpec.Type.(*ast.InterfaceType); !ok {
+					continue
+				}
+
+				doc := typeSpec.Doc
+				if doc == nil && !genDecl.Lparen.IsValid() {
+					doc = genDecl.Doc
+				}
+
+				if doc == nil {
+					continue
+				}
+
+				for _, comment := range doc.List {
+					if !hasPrefix(comment.Text, MarkerPrefix) {
+						continue
+					}
+
+					marker, err := parseMarker(comment.Text)
+					if err != nil {
+						return "", nil, fmt.Errorf("%s: %w", fset.Position(comment.Pos()), err)
+					}
+
+					position := fset.Position(typeSpec.Name.Pos())
+					marker.File = position.Filename
+					marker.Line = position.Line
+					marker.Ident = typeSpec.Name.Name
+					if marker.Output == "" {
+						marker.Output = snakeCase(marker.Ident) + ".go"
+					}
+
+					markers = append(markers, marker)
+				}
+			}
+		}
+	}
+
+	return pkg.Name, markers, nil
+}
+
+func parseMarker(text string) (*Marker, error) {
+	args := strings.Fields(trimPrefix(text, MarkerPrefix))
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no mode found in %s marker", quote(text))
+	}
+
+	marker := &Marker{Mode: args[0]}
+	if marker.Mode != ModeSqlx && marker.Mode != ModeApi {
+		return nil, fmt.Errorf("unsupported mode %s in marker, available modes are: %s",
+			quote(marker.Mode),
+			printStrings([]string{ModeSqlx, ModeApi}))
+	}
+
+	flags := pflag.NewFlagSet(MarkerPrefix+marker.Mode, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringSliceVarP(&marker.Features, "features", "f", nil, "features")
+	flags.StringVarP(&marker.Output, "output", "o", "", "output file name")
+	flags.StringVar(&marker.Schema, "schema", "", "mode=sqlx: validate sql against 'CREATE TABLE' statements in schema file")
+	flags.DurationVar(&marker.Timeout, "timeout", 0, "mode=sqlx: default timeout of methods without 'TIMEOUT=<duration>' feature")
+	if err := flags.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected args %s in marker", printStrings(flags.Args()))
+	}
+
+	if err := checkFeatures(marker.Features); err != nil {
+		return nil, err
+	}
+
+	return marker, nil
+}
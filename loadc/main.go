@@ -3,8 +3,11 @@ package main
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"go/token"
+	"go/types"
 	"os"
 	"strconv"
+	"time"
 )
 
 const (
@@ -27,6 +30,15 @@ var (
 	LineNum, _  = strconv.Atoi(os.Getenv(EnvGoLine))
 
 	FileContent []byte
+
+	// FileBase is the base of CurrentFile in FileSet, which converts token.Pos
+	// into offset of FileContent
+	FileBase = 1
+	FileSet  *token.FileSet
+
+	// TypesInfo is available only when package of CurrentFile is type-checked
+	TypesInfo *types.Info
+	TypesPkg  *types.Package
 )
 
 var (
@@ -34,6 +46,8 @@ var (
 	features []string
 	output   string
 	pointer  bool
+	schema   string
+	timeout  time.Duration
 )
 
 var loadc = &cobra.Command{
@@ -57,6 +71,14 @@ var loadc = &cobra.Command{
 			return err
 		}
 
+		switch mode {
+		case ModeSql, ModeApi, ModeSqlx:
+			var err error
+			if FileContent, err = read(join(CurrentDir, CurrentFile)); err != nil {
+				return fmt.Errorf("os.ReadFile(%s): %w", quote(join(CurrentDir, CurrentFile)), err)
+			}
+		}
+
 		switch mode {
 		case ModeSql:
 			return genSql(cmd, args)
@@ -74,8 +96,14 @@ var validFeatures = []string{
 	FeatureApiCache,
 	FeatureApiLog,
 	FeatureApiClient,
+	FeatureApiRetry,
+	FeatureApiMock,
 	FeatureSqlxLog,
 	FeatureSqlxRebind,
+	FeatureSqlxMock,
+	FeatureSqlxRetry,
+	FeatureSqlxTrace,
+	FeatureSqlxCache,
 }
 
 func checkFeatures(features []string) error {
@@ -104,12 +132,12 @@ func init() {
 	loadc.Flags().StringSliceVarP(&features, "features", "f", nil, "features")
 	loadc.Flags().StringVarP(&output, "output", "o", "", "output file name")
 	loadc.Flags().BoolVar(&pointer, "pointer", false, "mode=sql: make 'SqlLoader' pointer type (*ident)")
+	loadc.Flags().StringVar(&schema, "schema", "", "mode=sqlx: validate tables and columns in sql against 'CREATE TABLE' statements in schema file (best effort: identifiers in function call arguments and columns of sub-queries or CTEs are not checked)")
+	loadc.Flags().DurationVar(&timeout, "timeout", 0, "mode=sqlx: default timeout of methods without 'TIMEOUT=<duration>' feature")
 }
 
 func init() {
-	var err error
-	FileContent, err = read(join(CurrentDir, CurrentFile))
-	cobra.CheckErr(err)
+	loadc.AddCommand(generate, check)
 }
 
 func main() {
@@ -2,9 +2,14 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"go/ast"
+	"go/token"
 	"net/http"
+	"regexp"
 	"sort"
+	"strings"
+	"time"
 )
 
 // Method represents a method declaration in an interface
@@ -27,6 +32,9 @@ type Method struct {
 
 	// Source represents the raw file content
 	Source []byte
+
+	// Pos represents the position of method declaration in Source
+	Pos token.Pos
 }
 
 func (method *Method) SortIn() []string {
@@ -60,10 +68,61 @@ func (method *Method) TmplURL() string {
 	return ""
 }
 
+// PathPattern should only be used with '--mode=api' arg, it converts path
+// of TmplURL into a regular expression, the leading host (either literal or
+// action) and query are dropped, and each action matches one path segment
+func (method *Method) PathPattern() string {
+	tmplURL := method.TmplURL()
+	if index := strings.Index(tmplURL, "://"); index != -1 {
+		tmplURL = tmplURL[index+3:]
+		if index = strings.IndexByte(tmplURL, '/'); index != -1 {
+			tmplURL = tmplURL[index:]
+		} else {
+			tmplURL = ""
+		}
+	} else if strings.HasPrefix(tmplURL, "{{") {
+		if index = strings.Index(tmplURL, "}}"); index != -1 {
+			tmplURL = tmplURL[index+2:]
+		}
+	}
+	if index := strings.IndexByte(tmplURL, '?'); index != -1 {
+		tmplURL = tmplURL[:index]
+	}
+	if tmplURL == "" {
+		tmplURL = "/"
+	}
+
+	var pattern strings.Builder
+	pattern.WriteByte('^')
+	for {
+		start := strings.Index(tmplURL, "{{")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(tmplURL[start:], "}}")
+		if end == -1 {
+			break
+		}
+		pattern.WriteString(regexp.QuoteMeta(tmplURL[:start]))
+		pattern.WriteString("[^/]*")
+		tmplURL = tmplURL[start+end+2:]
+	}
+	pattern.WriteString(regexp.QuoteMeta(tmplURL))
+	pattern.WriteByte('$')
+
+	return pattern.String()
+}
+
 var availableMethods = []string{
 	http.MethodGet,
+	http.MethodHead,
 	http.MethodPost,
 	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
 }
 
 // MethodHTTP should only be used with '--mode=api' arg
@@ -79,6 +138,28 @@ func (method *Method) MethodHTTP() string {
 	return ""
 }
 
+// FormType should only be used with '--mode=api' arg, it returns the media
+// type declared by 'Content-Type' header when it is a form type, a method
+// declared with form type but no body builds its body from arguments
+func (method *Method) FormType() string {
+	headers := method.Header
+	if index := strings.Index(headers, "\r\n\r\n"); index != -1 {
+		headers = headers[:index]
+	}
+	for _, line := range split(headers, "\r\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found || !strings.EqualFold(trimSpace(key), "Content-Type") {
+			continue
+		}
+		mediaType, _, _ := strings.Cut(value, ";")
+		switch mediaType = strings.ToLower(trimSpace(mediaType)); mediaType {
+		case ApiFormMultipart, ApiFormUrlencoded:
+			return mediaType
+		}
+	}
+	return ""
+}
+
 var availableOperations = []string{
 	SqlxOpExec,
 	SqlxOpQuery,
@@ -110,6 +191,99 @@ func (method *Method) SqlFeatures() []string {
 	return nil
 }
 
+// SqlFeatureArgs should only be used with '--mode=sqlx' arg, it returns the
+// comma separated args of features like 'REDACT(password,token)' named feat,
+// args keep their case since they usually refer to params
+func (method *Method) SqlFeatureArgs(feat string) []string {
+	var featArgs []string
+	args := method.MetaArgs()
+	if len(args) < 3 {
+		return nil
+	}
+	for _, arg := range args[2:] {
+		prefix := toUpper(feat) + "("
+		if !hasPrefix(toUpper(arg), prefix) || !strings.HasSuffix(arg, ")") {
+			continue
+		}
+		for _, featArg := range strings.Split(arg[len(prefix):len(arg)-1], ",") {
+			if featArg = strings.TrimSpace(featArg); featArg != "" {
+				featArgs = append(featArgs, featArg)
+			}
+		}
+	}
+	return featArgs
+}
+
+// IsRedacted should only be used with '--mode=sqlx' arg, it reports whether
+// param ident is marked by 'REDACT(...)' feature
+func (method *Method) IsRedacted(ident string) bool {
+	for _, param := range method.SqlFeatureArgs(SqlxFeatRedact) {
+		if param == ident {
+			return true
+		}
+	}
+	return false
+}
+
+// SqlFeatureValue should only be used with '--mode=sqlx' arg, it returns the
+// value of feature like 'TIMEOUT=2s' named feat, and whether it is found
+func (method *Method) SqlFeatureValue(feat string) (string, bool) {
+	args := method.MetaArgs()
+	if len(args) < 3 {
+		return "", false
+	}
+	for _, arg := range args[2:] {
+		if key, value, ok := strings.Cut(arg, "="); ok && toUpper(key) == toUpper(feat) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Timeout should only be used with '--mode=sqlx' arg, it returns duration of
+// 'TIMEOUT=<duration>' feature, or the default one set by '--timeout' flag,
+// methods returning iterator have no timeout since their rows outlive call
+func (method *Method) Timeout() time.Duration {
+	if method.ReturnIterator() {
+		return 0
+	}
+	if value, ok := method.SqlFeatureValue(SqlxFeatTimeout); ok {
+		// value has been checked by checkTimeout
+		d, _ := time.ParseDuration(value)
+		return d
+	}
+	return timeout
+}
+
+// BatchArg should only be used with '--mode=sqlx' arg, it returns the ident
+// of the only slice param when method has 'BATCH' feature, or empty string
+// if there are other params besides context
+func (method *Method) BatchArg() string {
+	var batchArg string
+	for ident, ty := range method.In {
+		if isContextType(ident, ty, method.Source) {
+			continue
+		}
+		if !isSlice(ty) || batchArg != "" {
+			return ""
+		}
+		batchArg = ident
+	}
+	return batchArg
+}
+
+// ReturnReader should only be used with '--mode=api' arg, it reports whether
+// the response body should be returned to caller as 'io.ReadCloser' directly
+func (method *Method) ReturnReader() bool {
+	if len(method.Out) < 2 {
+		return false
+	}
+	if typ := typeOf(method.Out[0]); typ != nil {
+		return isNamedType(typ, PkgIO, "ReadCloser")
+	}
+	return getRepr(method.Out[0], method.Source) == ExprReadCloser
+}
+
 func (method *Method) HasContext() bool {
 	for ident, ty := range method.In {
 		if isContextType(ident, ty, method.Source) {
@@ -127,14 +301,75 @@ func (method *Method) HasContext() bool {
 	return false
 }
 
+// HasTxOptions should only be used with sqlx WithTx method, it reports whether
+// method accepts a '*sql.TxOptions' param
+func (method *Method) HasTxOptions() bool {
+	for _, ty := range method.In {
+		if isTxOptions(ty, method.Source) {
+			return true
+		}
+	}
+
+	for _, ty := range method.UnnamedIn {
+		if isTxOptions(ty, method.Source) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (method *Method) ReturnSlice() bool {
 	return len(method.Out) > 1 && isSlice(method.Out[0])
 }
 
+// ReturnIterator should only be used with '--mode=sqlx' arg
+func (method *Method) ReturnIterator() bool {
+	return len(method.Out) > 1 && isIterator(method.Out[0])
+}
+
+// Callback should only be used with '--mode=sqlx' arg, it returns the ident
+// of 'func(T) error' param which receives query results row by row
+func (method *Method) Callback() string {
+	for ident, ty := range method.In {
+		if isCallback(ty) {
+			return ident
+		}
+	}
+	return ""
+}
+
+// checkContextParam checks that context param is named 'ctx', which is the
+// name referred by generated code
+func checkContextParam(method *Method) error {
+	for ident, ty := range method.In {
+		if isContextType(ident, ty, method.Source) && ident != "ctx" {
+			return fmt.Errorf("context param of method %s should be named 'ctx', got %s",
+				quote(method.Ident),
+				quote(ident))
+		}
+	}
+	return nil
+}
+
+// signatureExprs returns all param and result types of methods
+func signatureExprs(methods []*Method) []ast.Expr {
+	exprs := make([]ast.Expr, 0, len(methods)*4)
+	for _, method := range methods {
+		for _, ident := range method.SortIn() {
+			exprs = append(exprs, method.In[ident])
+		}
+		exprs = append(exprs, method.UnnamedIn...)
+		exprs = append(exprs, method.Out...)
+	}
+	return exprs
+}
+
 func inspectMethod(node ast.Node, source []byte) (method *Method) {
 	field := node.(*ast.Field)
 	method = new(Method)
 	method.Source = source
+	method.Pos = field.Pos()
 	if field.Doc != nil {
 		method.Meta = trimSpace(
 			trimPrefix(field.Doc.List[0].Text,
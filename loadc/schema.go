@@ -0,0 +1,780 @@
+package main
+
+import (
+	"fmt"
+	"github.com/Boyux/mrpkg"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	SqlTokenIdent = iota
+	SqlTokenString
+	SqlTokenNumber
+	SqlTokenPlaceholder
+	SqlTokenNamed
+	SqlTokenVariable
+	SqlTokenPunct
+)
+
+type sqlToken struct {
+	kind int
+	text string
+}
+
+// upper returns upper-cased text of unquoted identifier, quoted identifier
+// is never a keyword so an empty string is returned
+func (token sqlToken) upper() string {
+	if token.kind != SqlTokenIdent || token.quoted() {
+		return ""
+	}
+	return toUpper(token.text)
+}
+
+func (token sqlToken) quoted() bool {
+	return len(token.text) > 1 && strings.IndexByte("\"`", token.text[0]) != -1
+}
+
+// name returns lower-cased identifier without quotes
+func (token sqlToken) name() string {
+	if token.quoted() {
+		return strings.ToLower(token.text[1 : len(token.text)-1])
+	}
+	return strings.ToLower(token.text)
+}
+
+func (token sqlToken) is(punct string) bool {
+	return token.kind == SqlTokenPunct && token.text == punct
+}
+
+// tokenizeSql splits sql into tokens, whitespaces and comments are dropped,
+// string literals, quoted identifiers and comments are recognized by
+// mrpkg.ScanSql, so that they agree with mrpkg.SplitSql at runtime
+func tokenizeSql(sql string) []sqlToken {
+	var (
+		tokens []sqlToken
+		code   = -1
+	)
+
+	flush := func(end int) {
+		if code != -1 {
+			tokens = tokenizeCode(tokens, sql[code:end])
+			code = -1
+		}
+	}
+
+	mrpkg.ScanSql(sql, func(kind int, start int, end int) {
+		if kind == mrpkg.SqlSegmentCode {
+			if code == -1 {
+				code = start
+			}
+			return
+		}
+
+		flush(start)
+		switch kind {
+		case mrpkg.SqlSegmentString:
+			tokens = append(tokens, sqlToken{kind: SqlTokenString, text: sql[start:end]})
+		case mrpkg.SqlSegmentIdent:
+			tokens = append(tokens, sqlToken{kind: SqlTokenIdent, text: sql[start:end]})
+		}
+	})
+
+	flush(len(sql))
+	return tokens
+}
+
+// tokenizeCode appends tokens of sql which contains no string literals,
+// quoted identifiers or comments, '?|', '?&' and '??' are taken as operators
+// rather than placeholders, and '::' (type cast) is not a named arg
+func tokenizeCode(tokens []sqlToken, sql string) []sqlToken {
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			i++
+		case ch == '?':
+			if i+1 < len(sql) && strings.IndexByte("|&?", sql[i+1]) != -1 {
+				tokens = append(tokens, sqlToken{kind: SqlTokenPunct, text: sql[i : i+2]})
+				i += 2
+			} else {
+				tokens = append(tokens, sqlToken{kind: SqlTokenPlaceholder, text: "?"})
+				i++
+			}
+		case ch == ':':
+			if i+1 < len(sql) && sql[i+1] == ':' {
+				tokens = append(tokens, sqlToken{kind: SqlTokenPunct, text: "::"})
+				i += 2
+			} else if j := scanIdent(sql, i+1); j > i+1 {
+				tokens = append(tokens, sqlToken{kind: SqlTokenNamed, text: sql[i+1 : j]})
+				i = j
+			} else {
+				tokens = append(tokens, sqlToken{kind: SqlTokenPunct, text: ":"})
+				i++
+			}
+		case ch >= '0' && ch <= '9':
+			j := i + 1
+			for j < len(sql) && (isIdentChar(sql[j]) || sql[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: SqlTokenNumber, text: sql[i:j]})
+			i = j
+		case (ch == '$' || ch == '@') && i+1 < len(sql) && (isIdentChar(sql[i+1]) || sql[i+1] == '@'):
+			// positional placeholders like '$1' and variables like '@var' are
+			// neither columns nor '?' placeholders
+			j := scanIdent(sql, i+2)
+			tokens = append(tokens, sqlToken{kind: SqlTokenVariable, text: sql[i:j]})
+			i = j
+		case isIdentChar(ch):
+			j := scanIdent(sql, i+1)
+			tokens = append(tokens, sqlToken{kind: SqlTokenIdent, text: sql[i:j]})
+			i = j
+		default:
+			tokens = append(tokens, sqlToken{kind: SqlTokenPunct, text: sql[i : i+1]})
+			i++
+		}
+	}
+	return tokens
+}
+
+func scanIdent(sql string, i int) int {
+	for i < len(sql) && (isIdentChar(sql[i]) || sql[i] == '$') {
+		i++
+	}
+	return i
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch >= 0x80
+}
+
+func splitStatements(tokens []sqlToken) [][]sqlToken {
+	var (
+		stmts [][]sqlToken
+		start int
+	)
+	for i, token := range tokens {
+		if token.is(";") {
+			if i > start {
+				stmts = append(stmts, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		stmts = append(stmts, tokens[start:])
+	}
+	return stmts
+}
+
+// SqlSchema maps table name to its column names, all names are lower-cased
+type SqlSchema map[string]map[string]bool
+
+func loadSchema(path string) (SqlSchema, error) {
+	if !isAbs(path) {
+		path = join(CurrentDir, path)
+	}
+
+	content, err := read(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile(%s): %w", quote(path), err)
+	}
+
+	return parseSchema(string(content)), nil
+}
+
+var schemaConstraints = []string{
+	"PRIMARY", "UNIQUE", "KEY", "INDEX", "CONSTRAINT", "FOREIGN", "CHECK", "FULLTEXT", "SPATIAL", "EXCLUDE",
+}
+
+// parseSchema collects tables and columns from 'CREATE TABLE' statements,
+// other statements are ignored
+func parseSchema(content string) SqlSchema {
+	schema := make(SqlSchema)
+	for _, stmt := range splitStatements(tokenizeSql(content)) {
+		i := 0
+		if i < len(stmt) && stmt[i].upper() == "CREATE" {
+			i++
+		} else {
+			continue
+		}
+		for i < len(stmt) && stmt[i].upper() != "TABLE" && !stmt[i].is("(") {
+			i++
+		}
+		if i == len(stmt) || stmt[i].upper() != "TABLE" {
+			continue
+		}
+		i++
+		if i+2 < len(stmt) && stmt[i].upper() == "IF" && stmt[i+1].upper() == "NOT" && stmt[i+2].upper() == "EXISTS" {
+			i += 3
+		}
+
+		var table string
+		for ; i < len(stmt) && !stmt[i].is("("); i++ {
+			if stmt[i].kind == SqlTokenIdent {
+				table = stmt[i].name()
+			}
+		}
+		if table == "" || i == len(stmt) {
+			continue
+		}
+
+		columns := make(map[string]bool)
+		depth, itemStart := 0, true
+		for i++; i < len(stmt); i++ {
+			token := stmt[i]
+			switch {
+			case token.is("("):
+				depth++
+			case token.is(")"):
+				depth--
+			case token.is(",") && depth == 0:
+				itemStart = true
+				continue
+			case itemStart && depth == 0 && token.kind == SqlTokenIdent:
+				if !hasString(schemaConstraints, token.upper()) {
+					columns[token.name()] = true
+				}
+			}
+			if depth < 0 {
+				break
+			}
+			itemStart = false
+		}
+
+		schema[table] = columns
+	}
+	return schema
+}
+
+func hasString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, keyword := range strings.Fields(`
+		ADD ALL AND ANY AS ASC BETWEEN BOTH BY CASE CAST COLLATE CONFLICT CROSS CURRENT_DATE
+		CURRENT_TIME CURRENT_TIMESTAMP DAY DEFAULT DELETE DESC DISTINCT DO DUPLICATE ELSE END
+		ESCAPE EXCEPT EXISTS FALSE FETCH FIRST FOR FROM FULL GROUP HAVING HOUR IGNORE ILIKE IN
+		INNER INSERT INTERSECT INTERVAL INTO IS JOIN KEY LAST LATERAL LEADING LEFT LIKE LIMIT LOCALTIME
+		LOCALTIMESTAMP LOCK LOCKED MINUTE MONTH NATURAL NEXT NO NOT NOTHING NOWAIT NULL NULLS
+		OF OFFSET ON ONLY OR ORDER OUTER OVER PARTITION RECURSIVE REPLACE RETURNING RIGHT ROW
+		ROWS SECOND SELECT SET SHARE SKIP SOME THEN TO TRAILING TRUE UNION UPDATE USING VALUES WEEK WHEN
+		WHERE WINDOW WITH YEAR
+	`) {
+		sqlKeywords[keyword] = true
+	}
+}
+
+func isKeyword(token sqlToken) bool {
+	return sqlKeywords[token.upper()]
+}
+
+// callKeywords are keywords which are also functions when followed by '('
+var callKeywords = map[string]bool{"CAST": true, "LEFT": true, "REPLACE": true, "RIGHT": true}
+
+// inCalls reports whether each token of stmt is directly inside parentheses
+// of a function call, where 'FROM' is part of syntax like 'EXTRACT(YEAR FROM
+// created_at)' or 'TRIM(BOTH ' ' FROM name)' rather than a table source
+func inCalls(stmt []sqlToken) []bool {
+	var (
+		calls = make([]bool, len(stmt))
+		stack []bool
+	)
+	for i, token := range stmt {
+		switch {
+		case token.is("("):
+			call := i > 0 && stmt[i-1].kind == SqlTokenIdent &&
+				(!isKeyword(stmt[i-1]) || callKeywords[stmt[i-1].upper()])
+			stack = append(stack, call)
+		case token.is(")"):
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			calls[i] = len(stack) > 0 && stack[len(stack)-1]
+		}
+	}
+	return calls
+}
+
+// sqlSource represents tables referred by a statement, derived tables (from
+// sub-queries or CTEs) make column checks impossible
+type sqlSource struct {
+	tables  map[string]string
+	derived bool
+}
+
+func inspectSources(stmt []sqlToken, schema SqlSchema) (*sqlSource, error) {
+	source := &sqlSource{tables: make(map[string]string)}
+	ctes := make(map[string]bool)
+
+	for i := 0; i < len(stmt); i++ {
+		// 'WITH name AS (' and ', name AS ('
+		if i+2 < len(stmt) && stmt[i].kind == SqlTokenIdent && !isKeyword(stmt[i]) &&
+			stmt[i+1].upper() == "AS" && stmt[i+2].is("(") && i > 0 &&
+			(stmt[i-1].upper() == "WITH" || stmt[i-1].upper() == "RECURSIVE" || stmt[i-1].is(",")) {
+			ctes[stmt[i].name()] = true
+			source.derived = true
+		}
+	}
+
+	calls := inCalls(stmt)
+	for i := 0; i < len(stmt); i++ {
+		if calls[i] {
+			continue
+		}
+
+		switch keyword := stmt[i].upper(); keyword {
+		case "FROM", "JOIN", "INTO", "UPDATE":
+			if keyword == "UPDATE" && i > 0 && (stmt[i-1].upper() == "KEY" || stmt[i-1].upper() == "DO") {
+				continue
+			}
+			for j := i + 1; j < len(stmt); {
+				if stmt[j].upper() == "ONLY" || stmt[j].upper() == "LATERAL" {
+					j++
+					continue
+				}
+				if stmt[j].is("(") {
+					source.derived = true
+					break
+				}
+				if stmt[j].kind != SqlTokenIdent || isKeyword(stmt[j]) {
+					break
+				}
+
+				table := stmt[j].name()
+				for j+2 < len(stmt) && stmt[j+1].is(".") && stmt[j+2].kind == SqlTokenIdent {
+					table = stmt[j+2].name()
+					j += 2
+				}
+				j++
+
+				if ctes[table] {
+					source.tables[table] = table
+				} else if _, ok := schema[table]; !ok {
+					return nil, fmt.Errorf("unknown table %s", quote(table))
+				} else {
+					source.tables[table] = table
+				}
+
+				alias := table
+				if j < len(stmt) && stmt[j].upper() == "AS" {
+					j++
+				}
+				if j < len(stmt) && stmt[j].kind == SqlTokenIdent && !isKeyword(stmt[j]) {
+					alias = stmt[j].name()
+					source.tables[alias] = table
+					j++
+				}
+
+				if keyword == "FROM" && j < len(stmt) && stmt[j].is(",") {
+					j++
+					continue
+				}
+				break
+			}
+		}
+	}
+
+	return source, nil
+}
+
+// checkColumns reports unknown columns referred in stmt, identifiers which
+// are keywords, functions, aliases or type names are skipped
+func checkColumns(stmt []sqlToken, schema SqlSchema, source *sqlSource) error {
+	aliases := make(map[string]bool)
+	for i := 1; i < len(stmt); i++ {
+		if stmt[i-1].upper() == "AS" && stmt[i].kind == SqlTokenIdent || isSelectAlias(stmt, i) {
+			aliases[stmt[i].name()] = true
+		}
+	}
+
+	calls := inCalls(stmt)
+	for i := 0; i < len(stmt); i++ {
+		token := stmt[i]
+		if token.kind != SqlTokenIdent || isKeyword(token) || isSelectAlias(stmt, i) {
+			continue
+		}
+
+		if i+1 < len(stmt) && stmt[i+1].is("(") {
+			continue
+		}
+
+		// typed literal like 'TIMESTAMP '2020-01-01'', the identifier is a type
+		if i+1 < len(stmt) && stmt[i+1].kind == SqlTokenString {
+			continue
+		}
+
+		if i > 0 && (stmt[i-1].upper() == "AS" || stmt[i-1].is("::")) {
+			continue
+		}
+
+		// qualified column 'alias.column'
+		if i+2 < len(stmt) && stmt[i+1].is(".") {
+			table, ok := source.tables[token.name()]
+			column := stmt[i+2]
+			i += 2
+			if !ok || column.is("*") || column.kind != SqlTokenIdent || source.derived {
+				continue
+			}
+			if columns, ok := schema[table]; ok && !columns[column.name()] {
+				return fmt.Errorf("unknown column %s in table %s", quote(column.name()), quote(table))
+			}
+			continue
+		}
+
+		name := token.name()
+		if _, isTable := source.tables[name]; isTable || aliases[name] || source.derived {
+			continue
+		}
+
+		var found bool
+		for _, table := range source.tables {
+			if schema[table][name] {
+				found = true
+				break
+			}
+		}
+
+		// arguments of function calls may contain dialect specific syntax, like
+		// 'YEAR' in 'EXTRACT(YEAR FROM created_at)' or 'SEPARATOR' in MySQL
+		// 'GROUP_CONCAT(name SEPARATOR ',')', which are not columns
+		if !found && len(source.tables) > 0 && !calls[i] {
+			return fmt.Errorf("unknown column %s", quote(name))
+		}
+	}
+
+	return nil
+}
+
+// isSelectAlias reports whether stmt[i] is an alias declared without 'AS',
+// such as 'b' in 'SELECT a b FROM t'
+func isSelectAlias(stmt []sqlToken, i int) bool {
+	if i == 0 || stmt[i].kind != SqlTokenIdent || isKeyword(stmt[i]) {
+		return false
+	}
+	prev := stmt[i-1]
+	if !(prev.kind == SqlTokenIdent && !isKeyword(prev) || prev.is(")") || prev.upper() == "END") {
+		return false
+	}
+	return i+1 == len(stmt) || stmt[i+1].is(",") || stmt[i+1].upper() == "FROM"
+}
+
+// selectColumns returns output column names of the top-level select list in
+// stmt, '*' is expanded into columns of referred tables, items without a name
+// (expressions without alias) are returned as empty string
+func selectColumns(stmt []sqlToken, schema SqlSchema, source *sqlSource) []string {
+	var start, end, depth int
+	for i, token := range stmt {
+		switch {
+		case token.is("("):
+			depth++
+		case token.is(")"):
+			depth--
+		case depth == 0 && token.upper() == "SELECT":
+			start, end = i+1, len(stmt)
+			if start < len(stmt) && stmt[start].upper() == "DISTINCT" {
+				start++
+			}
+		case depth == 0 && start > 0 && token.upper() == "FROM" && end == len(stmt):
+			end = i
+		}
+	}
+
+	if start == 0 {
+		return nil
+	}
+
+	var (
+		columns []string
+		items   [][]sqlToken
+		begin   = start
+	)
+	depth = 0
+	for i := start; i < end; i++ {
+		switch {
+		case stmt[i].is("("):
+			depth++
+		case stmt[i].is(")"):
+			depth--
+		case stmt[i].is(",") && depth == 0:
+			items = append(items, stmt[begin:i])
+			begin = i + 1
+		}
+	}
+	items = append(items, stmt[begin:end])
+
+	for _, item := range items {
+		n := len(item)
+		switch {
+		case n == 0:
+		case item[n-1].is("*"):
+			if n == 3 && item[1].is(".") {
+				columns = append(columns, sortedColumns(schema[source.tables[item[0].name()]])...)
+			} else {
+				for table := range tableSet(source) {
+					columns = append(columns, sortedColumns(schema[table])...)
+				}
+			}
+		case item[n-1].kind == SqlTokenIdent && (n == 1 || item[n-2].is(".") || item[n-2].upper() == "AS" ||
+			item[n-2].kind == SqlTokenIdent || item[n-2].is(")")):
+			columns = append(columns, item[n-1].name())
+		default:
+			columns = append(columns, "")
+		}
+	}
+
+	return columns
+}
+
+func tableSet(source *sqlSource) map[string]bool {
+	set := make(map[string]bool, len(source.tables))
+	for _, table := range source.tables {
+		set[table] = true
+	}
+	return set
+}
+
+func sortedColumns(columns map[string]bool) []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkSchema validates sql of method against schema, sql containing template
+// actions is skipped as it could only be rendered at runtime
+func checkSchema(method *Method, schema SqlSchema) error {
+	sql, err := readHeader(method.Header)
+	if err != nil {
+		return err
+	}
+
+	if hasAction(sql) {
+		return nil
+	}
+
+	var (
+		stmts        = splitStatements(tokenizeSql(sql))
+		placeholders int
+		named        []string
+		last         *sqlSource
+	)
+
+	for _, stmt := range stmts {
+		source, err := checkStatement(stmt, schema)
+		if err != nil {
+			return err
+		}
+
+		for _, token := range stmt {
+			switch token.kind {
+			case SqlTokenPlaceholder:
+				placeholders++
+			case SqlTokenNamed:
+				named = append(named, token.text)
+			}
+		}
+
+		last = source
+	}
+
+	feats := method.SqlFeatures()
+	if hasFeature(feats, SqlxFeatNamed) {
+		if params, ok := namedParams(method); ok {
+			for _, name := range named {
+				if !params[name] {
+					return fmt.Errorf("named arg %s not found in params of method %s",
+						quote(":"+name),
+						quote(method.Ident))
+				}
+			}
+		}
+	} else if !hasFeature(feats, SqlxFeatBatch) {
+		if count, ok := countArgs(method); ok && count != placeholders {
+			return fmt.Errorf("method %s has %d args but its sql expects %d",
+				quote(method.Ident),
+				count,
+				placeholders)
+		}
+	}
+
+	if method.SqlOperation() == SqlxOpQuery && len(stmts) > 0 {
+		if fields, structName, ok := resultFields(method); ok {
+			for _, column := range selectColumns(stmts[len(stmts)-1], schema, last) {
+				if column != "" && !fields[column] {
+					return fmt.Errorf("column %s has no matching 'db' field in %s",
+						quote(column),
+						structName)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkStatement checks tables and columns referred by stmt against schema
+func checkStatement(stmt []sqlToken, schema SqlSchema) (*sqlSource, error) {
+	source, err := inspectSources(stmt, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkColumns(stmt, schema, source); err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+// sqlArgs returns params passed as sql args
+func sqlArgs(method *Method) []ast.Expr {
+	args := make([]ast.Expr, 0, len(method.In))
+	for _, ident := range method.SortIn() {
+		ty := method.In[ident]
+		if !isContextType(ident, ty, method.Source) && !isCallback(ty) {
+			args = append(args, ty)
+		}
+	}
+	return args
+}
+
+// countArgs returns the number of args after mrpkg.MergeArgs, it fails when
+// any arg would be expanded, or its type could not be resolved
+func countArgs(method *Method) (int, bool) {
+	args := sqlArgs(method)
+	for _, arg := range args {
+		typ := typeOf(arg)
+		if typ == nil || hasMethod(typ, "ToArgs") || hasMethod(typ, "NotAnArg") {
+			return 0, false
+		}
+		if slice, ok := typ.Underlying().(*types.Slice); ok && !types.Identical(slice.Elem(), types.Typ[types.Byte]) {
+			return 0, false
+		}
+	}
+	return len(args), true
+}
+
+// namedParams returns names available after mrpkg.MergeNamedArgs, it fails
+// when names of any arg could not be determined
+func namedParams(method *Method) (map[string]bool, bool) {
+	params := make(map[string]bool, len(method.In))
+	for _, ident := range method.SortIn() {
+		ty := method.In[ident]
+		if isContextType(ident, ty, method.Source) || isCallback(ty) {
+			continue
+		}
+		typ := typeOf(ty)
+		if typ == nil || hasMethod(typ, "ToNamedArgs") {
+			return nil, false
+		}
+		if hasMethod(typ, "Value") {
+			params[ident] = true
+			continue
+		}
+		switch underlying := deref(typ).Underlying().(type) {
+		case *types.Map:
+			return nil, false
+		case *types.Struct:
+			for i := 0; i < underlying.NumFields(); i++ {
+				if tag, ok := reflect.StructTag(underlying.Tag(i)).Lookup("db"); ok {
+					params[tag] = true
+				}
+			}
+		default:
+			params[ident] = true
+		}
+	}
+	return params, true
+}
+
+// resultFields returns column names that could be scanned into the struct
+// returned by a QUERY method, following the default mapper of sqlx
+func resultFields(method *Method) (map[string]bool, string, bool) {
+	var elem types.Type
+	if callback := method.Callback(); callback != "" {
+		if typ, ok := typeOf(method.In[callback]).(*types.Signature); ok && typ.Params().Len() == 1 {
+			elem = typ.Params().At(0).Type()
+		}
+	} else if len(method.Out) > 1 {
+		elem = typeOf(method.Out[0])
+		if method.ReturnIterator() {
+			elem = iterElemType(method.Out[0])
+		} else if elem != nil {
+			if slice, ok := elem.Underlying().(*types.Slice); ok {
+				elem = slice.Elem()
+			}
+		}
+	}
+
+	if elem == nil {
+		return nil, "", false
+	}
+
+	elem = deref(elem)
+	structType, ok := elem.Underlying().(*types.Struct)
+	if !ok || hasMethod(elem, "Scan") {
+		return nil, "", false
+	}
+
+	// struct without exported fields (such as time.Time) is scanned as a
+	// single value by sqlx
+	fields := make(map[string]bool)
+	collectFields(structType, fields)
+	if len(fields) == 0 {
+		return nil, "", false
+	}
+
+	return fields, types.TypeString(elem, (*types.Package).Name), true
+}
+
+func collectFields(structType *types.Struct, fields map[string]bool) {
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		tag, hasTag := reflect.StructTag(structType.Tag(i)).Lookup("db")
+		if tag == "-" {
+			continue
+		}
+		if field.Embedded() && !hasTag {
+			if embedded, ok := deref(field.Type()).Underlying().(*types.Struct); ok {
+				collectFields(embedded, fields)
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			fields[strings.ToLower(name)] = true
+		} else {
+			fields[strings.ToLower(field.Name())] = true
+		}
+	}
+}
+
+func deref(typ types.Type) types.Type {
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return typ
+}
+
+func hasMethod(typ types.Type, name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
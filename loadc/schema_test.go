@@ -0,0 +1,102 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+CREATE TABLE IF NOT EXISTS user (
+    id         BIGINT      NOT NULL AUTO_INCREMENT,
+    name       VARCHAR(64) NOT NULL DEFAULT '',
+    tags       TEXT[],
+    created_at TIMESTAMP   NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_name (name)
+);
+
+CREATE TABLE "orders" (
+    id      BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    amount  BIGINT NOT NULL DEFAULT 0
+);
+`
+
+func TestParseSchema(t *testing.T) {
+	expect := SqlSchema{
+		"user":   {"id": true, "name": true, "tags": true, "created_at": true},
+		"orders": {"id": true, "user_id": true, "amount": true},
+	}
+
+	if got := parseSchema(testSchema); !reflect.DeepEqual(got, expect) {
+		t.Errorf("parseSchema: expect=%v; got=%v", expect, got)
+	}
+}
+
+func TestCheckStatement(t *testing.T) {
+	schema := parseSchema(testSchema)
+	for _, testCase := range []struct {
+		sql string
+		err string
+	}{
+		{sql: "SELECT id, name FROM user WHERE id = ?"},
+		{sql: "SELECT u.id, o.amount FROM user u JOIN orders AS o ON o.user_id = u.id"},
+		{sql: "SELECT EXTRACT(YEAR FROM created_at) AS year FROM user"},
+		{sql: "SELECT EXTRACT(EPOCH FROM created_at) FROM user"},
+		{sql: "SELECT TRIM(BOTH ' ' FROM name) FROM user"},
+		{sql: "SELECT id FROM user WHERE created_at > TIMESTAMP '2020-01-01'"},
+		{sql: "SELECT tags[1] FROM user"},
+		{sql: "SELECT GROUP_CONCAT(name ORDER BY id SEPARATOR ',') FROM user"},
+		{sql: "SELECT CAST(id AS CHAR CHARACTER SET utf8mb4) FROM user"},
+		{sql: "SELECT id FROM user WHERE id IN (SELECT user_id FROM orders WHERE amount > ?)"},
+		{sql: "SELECT COALESCE((SELECT amount FROM orders WHERE user_id = user.id), 0) FROM user"},
+		{sql: "SELECT id FROM user WHERE name = 'it''s [not] a FROM clause'"},
+		{sql: "SELECT \"name\" FROM user WHERE tags ?| ? AND id = $1"},
+		{sql: "UPDATE user SET name = :name WHERE id = :id"},
+		{sql: "INSERT INTO orders (user_id, amount) VALUES (?, ?)"},
+		{sql: "SELECT id FROM users", err: `unknown table "users"`},
+		{sql: "SELECT id FROM user WHERE id IN (SELECT id FROM missing)", err: `unknown table "missing"`},
+		{sql: "SELECT nickname FROM user", err: `unknown column "nickname"`},
+		{sql: "SELECT EXTRACT(YEAR FROM u.updated_at) FROM user u", err: `unknown column "updated_at" in table "user"`},
+		{sql: "SELECT o.price FROM orders o", err: `unknown column "price" in table "orders"`},
+	} {
+		stmts := splitStatements(tokenizeSql(testCase.sql))
+		if len(stmts) != 1 {
+			t.Errorf("splitStatements(%q): expect 1 statement, got %d", testCase.sql, len(stmts))
+			continue
+		}
+
+		_, err := checkStatement(stmts[0], schema)
+		switch {
+		case testCase.err == "" && err != nil:
+			t.Errorf("checkStatement(%q): expect no error, got %v", testCase.sql, err)
+		case testCase.err != "" && (err == nil || !strings.Contains(err.Error(), testCase.err)):
+			t.Errorf("checkStatement(%q): expect error %s, got %v", testCase.sql, testCase.err, err)
+		}
+	}
+}
+
+func TestTokenizeSql(t *testing.T) {
+	for sql, expect := range map[string][]string{
+		"SELECT tags[1] FROM t":            {"SELECT", "tags", "[", "1", "]", "FROM", "t"},
+		"a = 'x;y' -- comment\n AND b":     {"a", "=", "'x;y'", "AND", "b"},
+		"a ?| ? AND b::int = :c /* ? */":   {"a", "?|", "?", "AND", "b", "::", "int", "=", "c"},
+		"SELECT $body$ FROM t $body$, `x`": {"SELECT", "$body$ FROM t $body$", ",", "`x`"},
+	} {
+		var got []string
+		for _, token := range tokenizeSql(sql) {
+			got = append(got, token.text)
+		}
+		if !reflect.DeepEqual(got, expect) {
+			t.Errorf("tokenizeSql(%q): expect=%q; got=%q", sql, expect, got)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	sql := "SELECT ';' FROM t; UPDATE t SET a = $$;$$;; DELETE FROM t"
+	if got := splitStatements(tokenizeSql(sql)); len(got) != 3 {
+		t.Errorf("splitStatements(%q): expect 3 statements, got %d", sql, len(got))
+	}
+}
@@ -20,6 +20,25 @@ const (
 )
 
 func genSql(_ *cobra.Command, args []string) error {
+	code, err := buildSql(args)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = "sql.go"
+	}
+
+	if err = write(join(CurrentDir, output), code, FileMode); err != nil {
+		return fmt.Errorf("os.WriteFile(%s, %04x): %w", join(CurrentDir, output), FileMode, err)
+	}
+
+	return nil
+}
+
+// buildSql generates code for the type declared at LineNum+1 of CurrentFile,
+// with sql and template files in directory args[0]
+func buildSql(args []string) ([]byte, error) {
 	dir := args[0]
 	if !isAbs(dir) {
 		dir = join(CurrentDir, dir)
@@ -27,16 +46,16 @@ func genSql(_ *cobra.Command, args []string) error {
 
 	stat, err := os.Stat(dir)
 	if err != nil {
-		return fmt.Errorf("os.Stat(%s): %w", quote(dir), err)
+		return nil, fmt.Errorf("os.Stat(%s): %w", quote(dir), err)
 	}
 
 	if !stat.IsDir() {
-		return fmt.Errorf("expects directory, got file from %s", quote(dir))
+		return nil, fmt.Errorf("expects directory, got file from %s", quote(dir))
 	}
 
 	dirEntries, err := list(dir)
 	if err != nil {
-		return fmt.Errorf("os.ReadDir(%s): %w", quote(dir), err)
+		return nil, fmt.Errorf("os.ReadDir(%s): %w", quote(dir), err)
 	}
 
 	var (
@@ -69,7 +88,7 @@ func genSql(_ *cobra.Command, args []string) error {
 
 		content, err := read(join(dir, entryName))
 		if err != nil {
-			return fmt.Errorf("os.ReadFile(%s): %w", quote(join(dir, entryName)), err)
+			return nil, fmt.Errorf("os.ReadFile(%s): %w", quote(join(dir, entryName)), err)
 		}
 
 		targetMap[trimSuffix(base(entryName), targetExt)] = string(content)
@@ -77,28 +96,20 @@ func genSql(_ *cobra.Command, args []string) error {
 
 	inspectCtx, err := inspectSql(join(CurrentDir, CurrentFile), LineNum+1)
 	if err != nil {
-		return fmt.Errorf("inspectSql(%s, %d): %w", quote(join(CurrentDir, CurrentFile)), LineNum, err)
+		return nil, fmt.Errorf("inspectSql(%s, %d): %w", quote(join(CurrentDir, CurrentFile)), LineNum, err)
 	}
 
 	code, err := genSqlCode(PackageName, inspectCtx.Ident.Name, pointer, sqlMap, tmplMap)
 	if err != nil {
-		return fmt.Errorf("genSqlCode(%s, %s): %w", quote(PackageName), quote(inspectCtx.Ident.Name), err)
+		return nil, fmt.Errorf("genSqlCode(%s, %s): %w", quote(PackageName), quote(inspectCtx.Ident.Name), err)
 	}
 
 	fmtCode, err := format.Source(code)
 	if err != nil {
-		return fmt.Errorf("format.Source: \n\n%s\n\n%w", code, err)
-	}
-
-	if output == "" {
-		output = "sql.go"
+		return nil, fmt.Errorf("format.Source: \n\n%s\n\n%w", code, err)
 	}
 
-	if err = write(join(CurrentDir, output), fmtCode, FileMode); err != nil {
-		return fmt.Errorf("os.WriteFile(%s, %04x): %w", join(CurrentDir, output), FileMode, err)
-	}
-
-	return nil
+	return fmtCode, nil
 }
 
 type SqlContext struct {
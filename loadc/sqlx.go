@@ -7,10 +7,12 @@ import (
 	"github.com/spf13/cobra"
 	"go/ast"
 	"go/format"
-	"go/parser"
 	"go/token"
+	"go/types"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	_ "embed"
 )
@@ -20,6 +22,15 @@ const (
 	SqlxOpQuery = "QUERY"
 
 	SqlxFeatNamed = "NAMED"
+	SqlxFeatBatch = "BATCH"
+
+	SqlxFeatLastId   = "LASTID"
+	SqlxFeatAffected = "AFFECTED"
+	SqlxFeatPrimary  = "PRIMARY"
+	SqlxFeatVersion  = "VERSIONED"
+	SqlxFeatRedact   = "REDACT"
+	SqlxFeatTimeout  = "TIMEOUT"
+	SqlxFeatInvalid  = "INVALIDATES"
 
 	SqlxMethodWithTx = "WithTx"
 
@@ -27,60 +38,158 @@ const (
 
 	FeatureSqlxLog    = "sqlx/log"
 	FeatureSqlxRebind = "sqlx/rebind"
+	FeatureSqlxMock   = "sqlx/mock"
+	FeatureSqlxRetry  = "sqlx/retry"
+	FeatureSqlxTrace  = "sqlx/trace"
+	FeatureSqlxCache  = "sqlx/cache"
 )
 
 func genSqlx(_ *cobra.Command, _ []string) error {
-	inspectCtx, err := inspectSqlx(join(CurrentDir, CurrentFile), LineNum+1)
+	code, err := buildSqlx()
 	if err != nil {
-		return fmt.Errorf("inspectSqlx(%s, %d): %w", quote(join(CurrentDir, CurrentFile)), LineNum, err)
+		return err
 	}
 
-	for i, method := range inspectCtx.Methods {
-		if l := len(method.Out); l == 0 || !checkErrorType(method.Out[l-1]) {
-			return fmt.Errorf("checkErrorType: no 'error' found in method %s returned value",
-				quote(method.Ident))
-		}
+	if output == "" {
+		output = "sqlx.go"
+	}
 
-		if len(method.Out) > 2 {
-			return fmt.Errorf("%s method expects 2 returned value at most, got %d",
-				quote(method.Ident),
-				len(method.Out))
+	if err = write(join(CurrentDir, output), code, FileMode); err != nil {
+		return fmt.Errorf("os.WriteFile(%s, %04x): %w", join(CurrentDir, output), FileMode, err)
+	}
+
+	return nil
+}
+
+// buildSqlx generates code for the interface declared at LineNum+1 of CurrentFile
+func buildSqlx() ([]byte, error) {
+	inspectCtx, err := inspectSqlx(join(CurrentDir, CurrentFile), LineNum+1)
+	if err != nil {
+		return nil, fmt.Errorf("inspectSqlx(%s, %d): %w", quote(join(CurrentDir, CurrentFile)), LineNum, err)
+	}
+
+	methods := make([]*Method, 0, len(inspectCtx.Methods))
+	for _, method := range inspectCtx.Methods {
+		if err = checkSqlxMethod(method); err != nil {
+			return nil, fmt.Errorf("%s: %w", position(method.Pos), err)
 		}
 
 		if method.Ident == SqlxMethodWithTx {
 			inspectCtx.WithTx = true
 			inspectCtx.WithTxContext = method.HasContext()
-			inspectCtx.Methods = append(inspectCtx.Methods[:i], inspectCtx.Methods[i+1:]...)
+			inspectCtx.WithTxOptions = method.HasTxOptions()
+			continue
+		}
+
+		methods = append(methods, method)
+	}
+	inspectCtx.Methods = methods
+
+	for _, method := range inspectCtx.Methods {
+		if err = checkInvalidates(inspectCtx, method); err != nil {
+			return nil, fmt.Errorf("%s: %w", position(method.Pos), err)
+		}
+	}
+
+	if schema != "" {
+		sqlSchema, err := loadSchema(schema)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, method := range inspectCtx.Methods {
+			if err = checkSchema(method, sqlSchema); err != nil {
+				return nil, fmt.Errorf("%s: %w", position(method.Pos), err)
+			}
 		}
 	}
 
 	code, err := genSqlxCode(inspectCtx)
 	if err != nil {
-		return fmt.Errorf("genApiCode: \n\n%#v\n\n%w", inspectCtx, err)
+		return nil, fmt.Errorf("genApiCode: \n\n%#v\n\n%w", inspectCtx, err)
 	}
 
 	fmtCode, err := format.Source(code)
 	if err != nil {
-		return fmt.Errorf("format.Source: \n\n%s\n\n%w", code, err)
+		return nil, fmt.Errorf("format.Source: \n\n%s\n\n%w", code, err)
 	}
 
-	if output == "" {
-		output = "sqlx.go"
+	return dedupImports(fmtCode)
+}
+
+func checkSqlxMethod(method *Method) error {
+	if method.Ident == SqlxMethodWithTx {
+		return checkWithTx(method)
 	}
 
-	if err = write(join(CurrentDir, output), fmtCode, FileMode); err != nil {
-		return fmt.Errorf("os.WriteFile(%s, %04x): %w", join(CurrentDir, output), FileMode, err)
+	if l := len(method.Out); l == 0 || !checkErrorType(method.Out[l-1]) {
+		return fmt.Errorf("checkErrorType: no 'error' found in method %s returned value",
+			quote(method.Ident))
 	}
 
-	return nil
+	if len(method.Out) > 2 {
+		return fmt.Errorf("%s method expects 2 returned value at most, got %d",
+			quote(method.Ident),
+			len(method.Out))
+	}
+
+	if method.SqlOperation() == SqlxOpQuery && method.Callback() != "" && len(method.Out) != 1 {
+		return fmt.Errorf("%s method with callback param should only return 'error'",
+			quote(method.Ident))
+	}
+
+	if hasFeature(method.SqlFeatures(), SqlxFeatBatch) {
+		if err := checkBatch(method); err != nil {
+			return err
+		}
+	}
+
+	if err := checkExecResult(method); err != nil {
+		return err
+	}
+
+	if hasFeature(method.SqlFeatures(), SqlxFeatVersion) {
+		if method.SqlOperation() != SqlxOpExec {
+			return fmt.Errorf("%s method with %s feature should be %s operation",
+				quote(method.Ident),
+				SqlxFeatVersion,
+				SqlxOpExec)
+		}
+
+		if hasFeature(method.SqlFeatures(), SqlxFeatBatch) {
+			return fmt.Errorf("%s method can not use %s and %s features at the same time",
+				quote(method.Ident),
+				SqlxFeatVersion,
+				SqlxFeatBatch)
+		}
+	}
+
+	if err := checkRedact(method); err != nil {
+		return err
+	}
+
+	if err := checkTimeout(method); err != nil {
+		return err
+	}
+
+	if hasFeature(method.SqlFeatures(), SqlxFeatPrimary) && method.SqlOperation() != SqlxOpQuery {
+		return fmt.Errorf("%s method with %s feature should be %s operation",
+			quote(method.Ident),
+			SqlxFeatPrimary,
+			SqlxOpQuery)
+	}
+
+	return checkContextParam(method)
 }
 
 type SqlxContext struct {
 	Package       string
 	Ident         string
+	Imports       []string
 	Methods       []*Method
 	WithTx        bool
 	WithTxContext bool
+	WithTxOptions bool
 	Features      []string
 }
 
@@ -93,10 +202,50 @@ func (ctx *SqlxContext) HasFeature(feature string) bool {
 	return false
 }
 
-func inspectSqlx(file string, line int) (*SqlxContext, error) {
-	fset := token.NewFileSet()
+// HasTemplate reports whether any method has sql with template actions
+func (ctx *SqlxContext) HasTemplate() bool {
+	for _, method := range ctx.Methods {
+		if ok, _ := isTemplate(method.Header); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// HasNamedStmt reports whether any method caches its prepared statements,
+// which are methods with NAMED feature and static sql
+func (ctx *SqlxContext) HasNamedStmt() bool {
+	for _, method := range ctx.Methods {
+		if ok, _ := isTemplate(method.Header); !ok && hasFeature(method.SqlFeatures(), SqlxFeatNamed) {
+			return true
+		}
+	}
+	return false
+}
 
-	f, err := parser.ParseFile(fset, file, FileContent, parser.ParseComments)
+// HasTimeout reports whether any method runs with timeout
+func (ctx *SqlxContext) HasTimeout() bool {
+	for _, method := range ctx.Methods {
+		if method.Timeout() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportStrings reports whether package 'strings' is used by generated code,
+// which is only required by QUERY methods and BATCH methods
+func (ctx *SqlxContext) ImportStrings() bool {
+	for _, method := range ctx.Methods {
+		if method.SqlOperation() == SqlxOpQuery || hasFeature(method.SqlFeatures(), SqlxFeatBatch) {
+			return true
+		}
+	}
+	return false
+}
+
+func inspectSqlx(file string, line int) (*SqlxContext, error) {
+	fset, f, err := parseFile(file)
 	if err != nil {
 		return nil, err
 	}
@@ -146,8 +295,9 @@ inspectType:
 	for _, method := range ifaceType.Methods.List {
 		if name := method.Names[0].Name; name != SqlxMethodWithTx && !checkInput(method.Type.(*ast.FuncType)) {
 			return nil, fmt.Errorf(""+
-				"input params for method %s should "+
+				"%s: input params for method %s should "+
 				"contain 'Name' and 'Type' both",
+				position(method.Pos()),
 				quote(name))
 		}
 	}
@@ -159,16 +309,267 @@ inspectType:
 		}
 	}
 
+	methods := nodeMap(ifaceType.Methods.List, func(node ast.Node) *Method {
+		return inspectMethod(node, FileContent)
+	})
+
 	return &SqlxContext{
-		Package: PackageName,
-		Ident:   typeSpec.Name.Name,
-		Methods: nodeMap(ifaceType.Methods.List, func(node ast.Node) *Method {
-			return inspectMethod(node, FileContent)
-		}),
+		Package:  PackageName,
+		Ident:    typeSpec.Name.Name,
+		Imports:  sqlxImports(f, methods),
+		Methods:  methods,
 		Features: sqlxFeatures,
 	}, nil
 }
 
+// sqlxImports returns import specs required by method signatures, and by
+// element types of iterators which are printed by iterElem, duplicate specs
+// are removed by dedupImports after generation
+func sqlxImports(f *ast.File, methods []*Method) []string {
+	imports := computeImports(f, signatureExprs(methods))
+	for _, method := range methods {
+		if !method.ReturnIterator() {
+			continue
+		}
+		if elem := iterElemType(method.Out[0]); elem != nil {
+			imports = append(imports, typeImports(elem)...)
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// checkWithTx checks signature of WithTx method, which should be like
+// 'WithTx([context.Context], [*sql.TxOptions], func(Interface) error) error'
+func checkWithTx(method *Method) error {
+	params := method.UnnamedIn
+	for _, ident := range method.SortIn() {
+		params = append(params, method.In[ident])
+	}
+
+	if len(method.Out) != 1 || !checkErrorType(method.Out[0]) {
+		return fmt.Errorf("%s method should only return 'error'", quote(method.Ident))
+	}
+
+	if len(params) == 0 {
+		return fmt.Errorf("%s method expects 'func(T) error' as its last param", quote(method.Ident))
+	}
+
+	if _, ok := params[len(params)-1].(*ast.FuncType); !ok {
+		return fmt.Errorf("%s method expects 'func(T) error' as its last param", quote(method.Ident))
+	}
+
+	params = params[:len(params)-1]
+	if len(params) > 0 && isContextType("", params[0], method.Source) {
+		params = params[1:]
+	}
+	if len(params) > 0 && isTxOptions(params[0], method.Source) {
+		params = params[1:]
+	}
+
+	if len(params) > 0 {
+		return fmt.Errorf("%s method only accepts params 'context.Context' and '*sql.TxOptions' "+
+			"(in order) besides 'func(T) error'",
+			quote(method.Ident))
+	}
+
+	return nil
+}
+
+func checkBatch(method *Method) error {
+	if method.SqlOperation() != SqlxOpExec {
+		return fmt.Errorf("%s method with %s feature should be %s operation",
+			quote(method.Ident),
+			SqlxFeatBatch,
+			SqlxOpExec)
+	}
+
+	if hasFeature(method.SqlFeatures(), SqlxFeatNamed) {
+		return fmt.Errorf("%s method can not use %s and %s features at the same time",
+			quote(method.Ident),
+			SqlxFeatBatch,
+			SqlxFeatNamed)
+	}
+
+	if method.BatchArg() == "" {
+		return fmt.Errorf("%s method with %s feature expects exactly 1 slice param (except context)",
+			quote(method.Ident),
+			SqlxFeatBatch)
+	}
+
+	if len(method.Out) == 2 && !isInt64(method.Out[0], method.Source) {
+		return fmt.Errorf("%s method with %s feature should return 'error' or '(int64, error)'",
+			quote(method.Ident),
+			SqlxFeatBatch)
+	}
+
+	if slice, ok := typeOf(method.In[method.BatchArg()]).(*types.Slice); ok && !isBatchRow(slice.Elem()) {
+		return fmt.Errorf("%s method with %s feature expects rows of struct type %s "+
+			"implementing 'mrpkg.ToArgs', otherwise each row is passed as a single bind var",
+			quote(method.Ident),
+			SqlxFeatBatch,
+			quote(types.TypeString(slice.Elem(), qualifier)))
+	}
+
+	return nil
+}
+
+// checkRedact checks params in 'REDACT(...)' feature, which are replaced by
+// mrpkg.Redacted in args passed to Log with 'sqlx/log' feature, and in Args
+// of returned mrpkg.QueryError
+func checkRedact(method *Method) error {
+	params := method.SqlFeatureArgs(SqlxFeatRedact)
+	if len(params) == 0 {
+		return nil
+	}
+
+	if hasFeature(method.SqlFeatures(), SqlxFeatBatch) {
+		return fmt.Errorf("%s method can not use %s and %s features at the same time",
+			quote(method.Ident),
+			SqlxFeatRedact,
+			SqlxFeatBatch)
+	}
+
+	for _, param := range params {
+		ty, ok := method.In[param]
+		if !ok || isContextType(param, ty, method.Source) || isCallback(ty) {
+			return fmt.Errorf("%s method redacts unknown param %s",
+				quote(method.Ident),
+				quote(param))
+		}
+	}
+
+	return nil
+}
+
+// checkTimeout checks 'TIMEOUT=<duration>' feature, whose value is parsed by
+// time.ParseDuration, 'TIMEOUT=0' disables the default timeout of method
+func checkTimeout(method *Method) error {
+	value, ok := method.SqlFeatureValue(SqlxFeatTimeout)
+	if !ok {
+		return nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%s method has invalid %s feature: %w",
+			quote(method.Ident),
+			SqlxFeatTimeout,
+			err)
+	}
+
+	if d < 0 {
+		return fmt.Errorf("%s method expects non-negative %s, got %s",
+			quote(method.Ident),
+			SqlxFeatTimeout,
+			quote(value))
+	}
+
+	if d > 0 && method.ReturnIterator() {
+		return fmt.Errorf("%s method returning iterator can not use %s feature",
+			quote(method.Ident),
+			SqlxFeatTimeout)
+	}
+
+	return nil
+}
+
+// checkInvalidates checks 'INVALIDATES(...)' feature of EXEC method, whose
+// args are QUERY methods of the same interface cached by 'sqlx/cache'
+func checkInvalidates(ctx *SqlxContext, method *Method) error {
+	tags := method.SqlFeatureArgs(SqlxFeatInvalid)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if !ctx.HasFeature(FeatureSqlxCache) {
+		return fmt.Errorf("%s method with %s feature requires %s",
+			quote(method.Ident),
+			SqlxFeatInvalid,
+			quote(FeatureSqlxCache))
+	}
+
+	if method.SqlOperation() != SqlxOpExec {
+		return fmt.Errorf("%s method with %s feature should be %s operation",
+			quote(method.Ident),
+			SqlxFeatInvalid,
+			SqlxOpExec)
+	}
+
+Check:
+	for _, tag := range tags {
+		for _, query := range ctx.Methods {
+			if query.Ident == tag && query.SqlOperation() == SqlxOpQuery {
+				continue Check
+			}
+		}
+
+		return fmt.Errorf("%s method invalidates %s, which is not a %s method",
+			quote(method.Ident),
+			quote(tag),
+			SqlxOpQuery)
+	}
+
+	return nil
+}
+
+// checkExecResult checks returned values of EXEC method, which are 'error',
+// '(sql.Result, error)' for the last statement, '(mrpkg.Results, error)' for
+// all statements, or '(int64, error)' with either LASTID or AFFECTED feature
+func checkExecResult(method *Method) error {
+	var (
+		feats    = method.SqlFeatures()
+		lastId   = hasFeature(feats, SqlxFeatLastId)
+		affected = hasFeature(feats, SqlxFeatAffected)
+		batch    = hasFeature(feats, SqlxFeatBatch)
+	)
+
+	if !lastId && !affected {
+		if method.SqlOperation() == SqlxOpExec && !batch &&
+			len(method.Out) == 2 && isInt64(method.Out[0], method.Source) {
+			return fmt.Errorf("%s method returning 'int64' expects either %s or %s feature",
+				quote(method.Ident),
+				SqlxFeatLastId,
+				SqlxFeatAffected)
+		}
+		return nil
+	}
+
+	var feat = SqlxFeatLastId
+	if affected {
+		feat = SqlxFeatAffected
+	}
+
+	if method.SqlOperation() != SqlxOpExec {
+		return fmt.Errorf("%s method with %s feature should be %s operation",
+			quote(method.Ident),
+			feat,
+			SqlxOpExec)
+	}
+
+	if lastId && affected {
+		return fmt.Errorf("%s method can not use %s and %s features at the same time",
+			quote(method.Ident),
+			SqlxFeatLastId,
+			SqlxFeatAffected)
+	}
+
+	if lastId && batch {
+		return fmt.Errorf("%s method can not use %s and %s features at the same time",
+			quote(method.Ident),
+			SqlxFeatLastId,
+			SqlxFeatBatch)
+	}
+
+	if len(method.Out) != 2 || !isInt64(method.Out[0], method.Source) {
+		return fmt.Errorf("%s method with %s feature should return '(int64, error)'",
+			quote(method.Ident),
+			feat)
+	}
+
+	return nil
+}
+
 func readHeader(header string) (string, error) {
 	var buf bytes.Buffer
 	scanner := bufio.NewScanner(strings.NewReader(header))
@@ -193,6 +594,20 @@ func readHeader(header string) (string, error) {
 	return buf.String(), nil
 }
 
+// isTemplate reports whether sql in header contains template actions, static
+// sql is used as is at runtime rather than executing a template
+func isTemplate(header string) (bool, error) {
+	sql, err := readHeader(header)
+	if err != nil {
+		return false, err
+	}
+	return hasAction(sql), nil
+}
+
+func hasAction(sql string) bool {
+	return strings.Contains(sql, "{{")
+}
+
 func hasFeature(feats []string, feat string) bool {
 	for _, f := range feats {
 		if f == toUpper(feat) {
@@ -212,14 +627,22 @@ func genSqlxCode(ctx *SqlxContext) ([]byte, error) {
 			"quote":         quote,
 			"readHeader":    readHeader,
 			"hasFeature":    hasFeature,
+			"isTemplate":    isTemplate,
 			"isSlice":       isSlice,
+			"isResults":     isResults,
 			"isPointer":     isPointer,
 			"indirect":      indirect,
 			"isContextType": func(ident string, expr ast.Expr) bool { return isContextType(ident, expr, FileContent) },
+			"isCallback":    isCallback,
+			"camelize":      camelize,
+			"fieldType":     func(node ast.Node) string { return fieldType(node, FileContent) },
+			"callArg":       callArg,
+			"iterElem":      func(node ast.Node) string { return iterElem(node, FileContent) },
 			"sub":           func(x, y int) int { return x - y },
 			"getRepr":       func(node ast.Node) string { return getRepr(node, FileContent) },
 			"isQuery":       func(op string) bool { return op == SqlxOpQuery },
 			"isExec":        func(op string) bool { return op == SqlxOpExec },
+			"durationExpr":  durationExpr,
 		}).
 		Parse(SqlxTemplate)
 
@@ -5,16 +5,26 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"os"
 	"path"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
 const (
-	ExprErrorIdent   = "error"
-	ExprContextIdent = "Context"
+	ExprErrorIdent        = "error"
+	ExprContextIdent      = "Context"
+	ExprReaderPrefix      = "io.Read"
+	ExprReadCloser        = "io.ReadCloser"
+	ExprMrpkgIdent        = "mrpkg"
+	ExprIteratorIdent     = "RowsIterator"
+	ExprListIteratorIdent = "ListIterator"
+	ExprResultsIdent      = "Results"
+	ExprInt64Ident        = "int64"
+	ExprTxOptions         = "*sql.TxOptions"
 )
 
 var (
@@ -24,6 +34,7 @@ var (
 	trimPrefix = strings.TrimPrefix
 	trimSpace  = strings.TrimSpace
 	hasPrefix  = strings.HasPrefix
+	hasSuffix  = strings.HasSuffix
 	concat     = strings.Join
 	split      = strings.Split
 	toUpper    = strings.ToUpper
@@ -37,7 +48,7 @@ var (
 )
 
 func getRepr(node ast.Node, src []byte) string {
-	return string(src[node.Pos()-1 : node.End()-1])
+	return string(src[int(node.Pos())-FileBase : int(node.End())-FileBase])
 }
 
 func newType(expr ast.Expr, src []byte) string {
@@ -55,6 +66,24 @@ func newType(expr ast.Expr, src []byte) string {
 	}
 }
 
+// fieldType returns the type of node when it is used as a struct field,
+// variadic param '...T' would be converted to '[]T'
+func fieldType(node ast.Node, src []byte) string {
+	if ellipsis, ok := node.(*ast.Ellipsis); ok {
+		return "[]" + getRepr(ellipsis.Elt, src)
+	}
+	return getRepr(node, src)
+}
+
+// callArg returns ident as a call argument, variadic param would be
+// expanded as 'ident...'
+func callArg(ident string, node ast.Node) string {
+	if _, ok := node.(*ast.Ellipsis); ok {
+		return ident + "..."
+	}
+	return ident
+}
+
 func hit(fset *token.FileSet, node ast.Node, line int) bool {
 	pos, end := fset.Position(node.Pos()), fset.Position(node.End())
 	return pos.Line <= line && end.Line >= line
@@ -77,6 +106,92 @@ func isSlice(node ast.Node) bool {
 	return ok && typ.Len == nil
 }
 
+// isIterator reports whether node is '*mrpkg.RowsIterator[T]', or
+// 'mrpkg.ListIterator[T]' which is implemented by '*mrpkg.RowsIterator[T]'
+func isIterator(node ast.Node) bool {
+	if expr, ok := node.(ast.Expr); ok {
+		if typ := typeOf(expr); typ != nil {
+			if ptr, ok := typ.(*types.Pointer); ok {
+				return isNamedType(ptr.Elem(), PkgMrpkg, ExprIteratorIdent)
+			}
+			return isNamedType(typ, PkgMrpkg, ExprListIteratorIdent)
+		}
+	}
+	if star, ok := node.(*ast.StarExpr); ok {
+		index, ok := star.X.(*ast.IndexExpr)
+		return ok && isMrpkgIdent(index.X, ExprIteratorIdent)
+	}
+	index, ok := node.(*ast.IndexExpr)
+	return ok && isMrpkgIdent(index.X, ExprListIteratorIdent)
+}
+
+// isMrpkgIdent reports whether node is 'mrpkg.<name>'
+func isMrpkgIdent(node ast.Node, name string) bool {
+	sel, ok := node.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == ExprMrpkgIdent && sel.Sel.Name == name
+}
+
+// isResults reports whether node is 'mrpkg.Results' or '*mrpkg.Results'
+func isResults(node ast.Node) bool {
+	node = indirect(node)
+	if expr, ok := node.(ast.Expr); ok {
+		if typ := typeOf(expr); typ != nil {
+			return isNamedType(typ, PkgMrpkg, ExprResultsIdent)
+		}
+	}
+	sel, ok := node.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == ExprMrpkgIdent && sel.Sel.Name == ExprResultsIdent
+}
+
+// isInt64 reports whether node is 'int64', or a type alias of 'int64'
+func isInt64(node ast.Node, src []byte) bool {
+	if expr, ok := node.(ast.Expr); ok {
+		if typ := typeOf(expr); typ != nil {
+			return types.Identical(typ, types.Typ[types.Int64])
+		}
+	}
+	return getRepr(node, src) == ExprInt64Ident
+}
+
+// isTxOptions reports whether node is '*sql.TxOptions'
+func isTxOptions(node ast.Node, src []byte) bool {
+	if expr, ok := node.(ast.Expr); ok {
+		if typ := typeOf(expr); typ != nil {
+			ptr, ok := typ.(*types.Pointer)
+			return ok && isNamedType(ptr.Elem(), PkgSql, "TxOptions")
+		}
+	}
+	return getRepr(node, src) == ExprTxOptions
+}
+
+// iterElem returns 'T' of '*mrpkg.RowsIterator[T]' or 'mrpkg.ListIterator[T]',
+// which is taken from the resolved type if possible, since the iterator could
+// be declared by an alias like 'type UserIter = *mrpkg.RowsIterator[User]'
+func iterElem(node ast.Node, src []byte) string {
+	if expr, ok := node.(ast.Expr); ok {
+		if elem := iterElemType(expr); elem != nil {
+			return types.TypeString(elem, qualifier)
+		}
+	}
+	return getRepr(indirect(node).(*ast.IndexExpr).Index, src)
+}
+
+// isCallback reports whether node is 'func(T) error'
+func isCallback(node ast.Node) bool {
+	fn, ok := node.(*ast.FuncType)
+	return ok &&
+		fn.Params != nil && len(fn.Params.List) == 1 && len(fn.Params.List[0].Names) <= 1 &&
+		fn.Results != nil && len(fn.Results.List) == 1 && checkErrorType(fn.Results.List[0].Type)
+}
+
 func checkInput(method *ast.FuncType) bool {
 	for _, param := range method.Params.List {
 		if len(param.Names) == 0 {
@@ -87,14 +202,30 @@ func checkInput(method *ast.FuncType) bool {
 }
 
 func checkErrorType(expr ast.Expr) bool {
+	if typ := typeOf(expr); typ != nil {
+		return types.Identical(typ, errorType)
+	}
 	ident, ok := expr.(*ast.Ident)
 	return ok && ident.Name == ExprErrorIdent
 }
 
 func isContextType(ident string, expr ast.Expr, src []byte) bool {
+	if typ := typeOf(expr); typ != nil {
+		return isNamedType(typ, PkgContext, ExprContextIdent)
+	}
 	return ident == "ctx" || strings.Contains(getRepr(expr, src), ExprContextIdent)
 }
 
+// isReaderType reports whether expr is one of 'io.Reader', 'io.ReadCloser',
+// 'io.ReadSeeker', etc., or any type implements 'io.Reader' when the type
+// could be resolved
+func isReaderType(expr ast.Expr, src []byte) bool {
+	if typ := typeOf(expr); typ != nil {
+		return types.Implements(typ, readerType)
+	}
+	return hasPrefix(getRepr(expr, src), ExprReaderPrefix)
+}
+
 func nodeMap[T ast.Node, U any](src []T, f func(ast.Node) U) []U {
 	dst := make([]U, len(src))
 	for i := 0; i < len(dst); i++ {
@@ -122,6 +253,24 @@ func camelize(snake string) (camel string) {
 	return string(dst)
 }
 
+// snakeCase converts camel case ident to snake case, consecutive upper case
+// letters are treated as one word, e.g. 'HTTPClient' becomes 'http_client'
+func snakeCase(camel string) string {
+	runes := []rune(camel)
+	dst := make([]rune, 0, len(runes)+4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				dst = append(dst, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		dst = append(dst, r)
+	}
+	return string(dst)
+}
+
 func splitArgs(line string) (args []string) {
 	var (
 		CurlyBraceStack int
@@ -177,3 +326,24 @@ func printStrings(strings []string) string {
 	}
 	return buf.String()
 }
+
+// durationExpr returns Go expression of d in its largest exact unit, such as
+// '2 * time.Second' or '1500 * time.Millisecond'
+func durationExpr(d time.Duration) string {
+	units := []struct {
+		unit time.Duration
+		expr string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+	for _, unit := range units {
+		if d%unit.unit == 0 {
+			return fmt.Sprintf("%d * %s", d/unit.unit, unit.expr)
+		}
+	}
+	return fmt.Sprintf("%d * time.Nanosecond", d)
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	PkgContext = "context"
+	PkgIO      = "io"
+	PkgSql     = "database/sql"
+	PkgMrpkg   = "github.com/Boyux/mrpkg"
+)
+
+const loadMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedSyntax
+
+var (
+	errorType  = types.Universe.Lookup("error").Type()
+	readerType = types.NewInterfaceType([]*types.Func{
+		types.NewFunc(token.NoPos, nil, "Read", types.NewSignatureType(nil, nil, nil,
+			types.NewTuple(types.NewVar(token.NoPos, nil, "p", types.NewSlice(types.Typ[types.Byte]))),
+			types.NewTuple(
+				types.NewVar(token.NoPos, nil, "n", types.Typ[types.Int]),
+				types.NewVar(token.NoPos, nil, "err", errorType),
+			),
+			false)),
+	}, nil).Complete()
+)
+
+var loadedPackages = make(map[string]*packages.Package)
+
+// loadPackage loads and type-checks package in dir, type errors are ignored
+// since generated files are usually out of date before generation
+func loadPackage(dir string) (*packages.Package, error) {
+	if pkg, ok := loadedPackages[dir]; ok {
+		return pkg, nil
+	}
+
+	fset := token.NewFileSet()
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: loadMode,
+		Dir:  dir,
+		Fset: fset,
+	}, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expects exactly 1 package, got %d", len(pkgs))
+	}
+
+	pkg := pkgs[0]
+	for _, pkgErr := range pkg.Errors {
+		if pkgErr.Kind != packages.TypeError {
+			return nil, pkgErr
+		}
+	}
+
+	pkg.Fset = fset
+
+	if exports, err := listExports(dir); err == nil {
+		pkg.Types, pkg.TypesInfo = typeCheck(pkg, exports)
+	}
+
+	loadedPackages[dir] = pkg
+	return pkg, nil
+}
+
+// typeCheck type-checks pkg against export data of its dependencies, which
+// is built by the go command so that its format always matches the importer
+func typeCheck(pkg *packages.Package, exports map[string]string) (*types.Package, *types.Info) {
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+
+	config := &types.Config{
+		Importer: importer.ForCompiler(pkg.Fset, "gc", func(importPath string) (io.ReadCloser, error) {
+			if export := exports[importPath]; export != "" {
+				return os.Open(export)
+			}
+			return nil, fmt.Errorf("no export data found for package %s", quote(importPath))
+		}),
+		Error: func(error) {},
+	}
+
+	typesPkg, _ := config.Check(pkg.PkgPath, pkg.Fset, pkg.Syntax, info)
+	return typesPkg, info
+}
+
+// listExports returns export data files of all dependencies of package in
+// dir, keyed by import path
+func listExports(dir string) (map[string]string, error) {
+	cmd := exec.Command("go", "list", "-e", "-deps", "-export", "-f", "{{ .ImportPath }}={{ .Export }}", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w", err)
+	}
+
+	exports := make(map[string]string)
+	for _, line := range split(trimSpace(string(out)), "\n") {
+		if importPath, export, found := strings.Cut(line, "="); found {
+			exports[importPath] = export
+		}
+	}
+
+	return exports, nil
+}
+
+// parseFile parses file as part of its type-checked package, which makes
+// TypesInfo available, or parses file alone if its package fails to load,
+// in which case loadc falls back to inspect types by AST text
+func parseFile(file string) (*token.FileSet, *ast.File, error) {
+	if pkg, err := loadPackage(path.Dir(file)); err == nil {
+		for _, f := range pkg.Syntax {
+			if tokenFile := pkg.Fset.File(f.Pos()); tokenFile != nil && tokenFile.Name() == file {
+				FileSet, FileBase, TypesInfo, TypesPkg = pkg.Fset, tokenFile.Base(), pkg.TypesInfo, pkg.Types
+				return pkg.Fset, f, nil
+			}
+		}
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, FileContent, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	FileSet, FileBase, TypesInfo, TypesPkg = fset, 1, nil, nil
+	return fset, f, nil
+}
+
+// position returns 'file:line:column' of pos in CurrentFile
+func position(pos token.Pos) string {
+	if FileSet == nil {
+		return join(CurrentDir, CurrentFile)
+	}
+	return FileSet.Position(pos).String()
+}
+
+// typeOf returns the resolved type of expr, or nil if expr could not be
+// resolved by type checker, variadic param '...T' is resolved as '[]T'
+func typeOf(expr ast.Expr) types.Type {
+	if TypesInfo == nil {
+		return nil
+	}
+
+	if ellipsis, ok := expr.(*ast.Ellipsis); ok {
+		if elem := typeOf(ellipsis.Elt); elem != nil {
+			return types.NewSlice(elem)
+		}
+		return nil
+	}
+
+	typ := TypesInfo.TypeOf(expr)
+	if typ == nil || typ == types.Typ[types.Invalid] {
+		return nil
+	}
+
+	return unalias(typ)
+}
+
+// unalias resolves type alias to its actual type, 'types.Unalias' is not
+// used here as it is not available in older go versions
+func unalias(typ types.Type) types.Type {
+	for {
+		alias, ok := typ.(interface{ Rhs() types.Type })
+		if !ok {
+			return typ
+		}
+		typ = alias.Rhs()
+	}
+}
+
+// isNamedType reports whether typ is (or is an instance of) the named type
+// declared as 'name' in package 'pkgPath'
+func isNamedType(typ types.Type, pkgPath string, name string) bool {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkgPath && obj.Name() == name
+}
+
+// iterElemType returns the resolved 'T' of '*mrpkg.RowsIterator[T]' or
+// 'mrpkg.ListIterator[T]', or nil if expr could not be resolved by type checker
+func iterElemType(expr ast.Expr) types.Type {
+	var (
+		typ   = typeOf(expr)
+		ident = ExprListIteratorIdent
+	)
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ, ident = ptr.Elem(), ExprIteratorIdent
+	}
+	named, ok := typ.(*types.Named)
+	if !ok || !isNamedType(named, PkgMrpkg, ident) || named.TypeArgs().Len() != 1 {
+		return nil
+	}
+	return named.TypeArgs().At(0)
+}
+
+// isBatchRow reports whether rows of typ could be flattened into bind vars
+// by mrpkg.MergeBatchArgs, struct rows should implement 'mrpkg.ToArgs' unless
+// they are single values like 'time.Time' or 'driver.Valuer'
+func isBatchRow(typ types.Type) bool {
+	for _, method := range []string{"ToArgs", "Value"} {
+		if obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, method); obj != nil {
+			if _, ok := obj.(*types.Func); ok {
+				return true
+			}
+		}
+	}
+
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+
+	if isNamedType(typ, "time", "Time") {
+		return true
+	}
+
+	_, isStruct := typ.Underlying().(*types.Struct)
+	return !isStruct
+}
+
+// qualifier qualifies types by package name, except those declared in the
+// package of CurrentFile
+func qualifier(pkg *types.Package) string {
+	if TypesPkg != nil && pkg.Path() == TypesPkg.Path() {
+		return ""
+	}
+	return pkg.Name()
+}
+
+// typeImports returns import specs of packages referred by typ, which is
+// printed with qualifier, such as '"database/sql"'
+func typeImports(typ types.Type) []string {
+	var (
+		imports []string
+		walk    func(types.Type)
+	)
+
+	addImport := func(obj *types.TypeName) {
+		if pkg := obj.Pkg(); pkg != nil && qualifier(pkg) != "" {
+			imports = append(imports, quote(pkg.Path()))
+		}
+	}
+
+	walk = func(typ types.Type) {
+		if alias, ok := typ.(interface {
+			Obj() *types.TypeName
+			Rhs() types.Type
+		}); ok {
+			// alias is printed by its own name
+			addImport(alias.Obj())
+			return
+		}
+		switch typ := typ.(type) {
+		case *types.Named:
+			addImport(typ.Obj())
+			for i := 0; i < typ.TypeArgs().Len(); i++ {
+				walk(typ.TypeArgs().At(i))
+			}
+		case *types.Pointer:
+			walk(typ.Elem())
+		case *types.Slice:
+			walk(typ.Elem())
+		case *types.Array:
+			walk(typ.Elem())
+		case *types.Map:
+			walk(typ.Key())
+			walk(typ.Elem())
+		}
+	}
+
+	walk(typ)
+	return imports
+}
+
+// computeImports returns import specs required by package qualifiers used
+// in exprs, such as '"database/sql"' or 'pg "github.com/lib/pq"'
+func computeImports(f *ast.File, exprs []ast.Expr) []string {
+	seen := make(map[string]struct{}, len(exprs))
+	imports := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		ast.Inspect(expr, func(node ast.Node) bool {
+			sel, ok := node.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				if spec := importOf(f, ident); spec != "" {
+					if _, exists := seen[spec]; !exists {
+						seen[spec] = struct{}{}
+						imports = append(imports, spec)
+					}
+				}
+			}
+			return false
+		})
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+func importOf(f *ast.File, ident *ast.Ident) string {
+	if TypesInfo != nil {
+		if pkgName, ok := TypesInfo.Uses[ident].(*types.PkgName); ok {
+			imported := pkgName.Imported()
+			if imported.Name() == ident.Name {
+				return quote(imported.Path())
+			}
+			return ident.Name + " " + quote(imported.Path())
+		}
+		return ""
+	}
+
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == ident.Name {
+				return ident.Name + " " + quote(importPath)
+			}
+		} else if base(importPath) == ident.Name {
+			return quote(importPath)
+		}
+	}
+
+	return ""
+}
+
+// dedupImports removes duplicate import specs in code, which happens when
+// computed imports overlap with imports declared by template
+func dedupImports(code []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", code, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var modified bool
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+
+		seen := make(map[string]struct{}, len(genDecl.Specs))
+		specs := genDecl.Specs[:0]
+		for _, spec := range genDecl.Specs {
+			importSpec := spec.(*ast.ImportSpec)
+			key := importSpec.Path.Value
+			if importSpec.Name != nil {
+				key = importSpec.Name.Name + " " + key
+			}
+			if _, exists := seen[key]; exists {
+				modified = true
+				continue
+			}
+			seen[key] = struct{}{}
+			specs = append(specs, spec)
+		}
+		genDecl.Specs = specs
+	}
+
+	if !modified {
+		return code, nil
+	}
+
+	var dst bytes.Buffer
+	if err = format.Node(&dst, fset, f); err != nil {
+		return nil, err
+	}
+
+	return format.Source(dst.Bytes())
+}
@@ -2,9 +2,13 @@ package mrpkg
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
 	"text/template"
@@ -156,3 +160,281 @@ func GenBindVars(data any) string {
 
 	return strings.Join(bindVars, ", ")
 }
+
+// MaxBindVars is the maximum number of bind vars allowed in a single
+// statement, 65535 is the limit of both MySQL and PostgreSQL, lower it
+// for drivers with smaller limits (e.g. 999 for legacy SQLite)
+var MaxBindVars = 65535
+
+// BatchSize returns the number of rows that fit in a single statement
+// when each row takes n bind vars, rows taking no bind vars (n <= 0) are
+// counted as taking 1, so that a statement never exceeds MaxBindVars rows,
+// the result is always positive since Chunk takes size <= 0 as unlimited
+func BatchSize(n int) int {
+	return Max(MaxBindVars/Max(n, 1), 1)
+}
+
+// MergeBatchArgs flattens rows into bind vars row by row, a row implementing
+// ToArgs (with either value or pointer receiver) is expanded by ToArgs, other
+// rows are single bind vars, loadc rejects BATCH methods with struct rows not
+// implementing ToArgs
+func MergeBatchArgs[T any](rows []T) []any {
+	dst := make([]any, 0, len(rows))
+	for i := 0; i < len(rows); i++ {
+		if toArgs, ok := any(&rows[i]).(ToArgs); ok {
+			dst = append(dst, MergeArgs(toArgs.ToArgs()...)...)
+		} else {
+			dst = append(dst, MergeArgs(rows[i])...)
+		}
+	}
+	return dst
+}
+
+// ExpandValues repeats the first parenthesized tuple following 'VALUES'
+// keyword in sql n times, so that 'INSERT INTO t (a, b) VALUES (?, ?)'
+// becomes 'INSERT INTO t (a, b) VALUES (?, ?), (?, ?)' with n = 2
+func ExpandValues(sql string, n int) (string, error) {
+	if n <= 0 {
+		return "", fmt.Errorf("ExpandValues: expects at least 1 row, got %d", n)
+	}
+
+	var (
+		keyword = -1
+		start   = -1
+		end     = -1
+		depth   int
+	)
+
+	// 'VALUES' keyword and parentheses inside string literals, quoted
+	// identifiers or comments are skipped by scanSql
+	scanSql(sql, func(i int) {
+		switch {
+		case end != -1:
+		case keyword == -1:
+			if isKeywordAt(sql, i, "VALUES") {
+				keyword = i
+			}
+		case sql[i] == '(':
+			if start == -1 {
+				start = i
+			}
+			depth++
+		case sql[i] == ')' && start != -1:
+			if depth--; depth == 0 {
+				end = i + 1
+			}
+		}
+	})
+
+	if keyword == -1 {
+		return "", fmt.Errorf("ExpandValues: no 'VALUES' clause found in sql: \n\n%s\n\n", sql)
+	}
+
+	if start == -1 {
+		return "", fmt.Errorf("ExpandValues: no tuple found after 'VALUES' in sql: \n\n%s\n\n", sql)
+	}
+
+	if end == -1 {
+		return "", fmt.Errorf("ExpandValues: unclosed tuple after 'VALUES' in sql: \n\n%s\n\n", sql)
+	}
+
+	if CountBindVars(sql[:start]) > 0 || CountBindVars(sql[end:]) > 0 {
+		return "", fmt.Errorf("ExpandValues: bind vars are only allowed inside the 'VALUES' tuple: \n\n%s\n\n", sql)
+	}
+
+	var dst strings.Builder
+	dst.Grow(len(sql) + (end-start+2)*(n-1))
+	dst.WriteString(sql[:end])
+	for i := 1; i < n; i++ {
+		dst.WriteString(", ")
+		dst.WriteString(sql[start:end])
+	}
+	dst.WriteString(sql[end:])
+
+	return dst.String(), nil
+}
+
+// Results aggregates sql.Result of each statement executed by a multi-statement
+// EXEC method in order, it implements sql.Result itself
+type Results struct {
+	Statements []sql.Result
+}
+
+// LastInsertId returns the id generated by the last statement
+func (results *Results) LastInsertId() (int64, error) {
+	if len(results.Statements) == 0 {
+		return 0, errors.New("Results.LastInsertId: no statement executed")
+	}
+	return results.Statements[len(results.Statements)-1].LastInsertId()
+}
+
+// RowsAffected returns the total number of rows affected by all statements
+func (results *Results) RowsAffected() (int64, error) {
+	var total int64
+	for i, result := range results.Statements {
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("Results.RowsAffected: statement %d: %w", i, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// Rows represents a cursor of query results, which is implemented by *sqlx.Rows
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	StructScan(dest any) error
+	Err() error
+	Close() error
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// isScannable reports whether typ should be scanned by Rows.Scan instead
+// of Rows.StructScan, which follows the same rule as sqlx.Select
+func isScannable(typ reflect.Type) bool {
+	if reflect.PointerTo(typ).Implements(scannerType) {
+		return true
+	}
+	if typ.Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+func scanRow[T any](rows Rows) (v T, err error) {
+	v = New[T]()
+	rv := reflect.ValueOf(&v).Elem()
+	dest := rv.Addr().Interface()
+	if rv.Kind() == reflect.Pointer {
+		dest = v
+		rv = rv.Elem()
+	}
+	if isScannable(rv.Type()) {
+		err = rows.Scan(dest)
+	} else {
+		err = rows.StructScan(dest)
+	}
+	return v, err
+}
+
+// NewRowsIterator wraps rows as a ListIterator which scans one row for each
+// Value call, rows would be closed once the iterator is exhausted or ctx is
+// done, callers should Close the iterator otherwise, an abandoned iterator
+// closes rows when it is garbage collected as a last resort, which may hold
+// the connection much longer than expected
+func NewRowsIterator[T any](ctx context.Context, rows Rows) *RowsIterator[T] {
+	iter := &RowsIterator[T]{
+		ctx:  ctx,
+		rows: rows,
+	}
+	runtime.SetFinalizer(iter, (*RowsIterator[T]).Close)
+	return iter
+}
+
+// RowsIterator is a ListIterator over query results, errors occurred while
+// iterating stop the iteration and could be retrieved by Err, callers should
+// always check Err after the iteration and Close the iterator, such as:
+//
+//	iter, err := handler.Iterate(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer iter.Close()
+//	for iter.Next() {
+//		user := iter.Value()
+//		...
+//	}
+//	return iter.Err()
+//
+// Methods returning 'mrpkg.ListIterator[T]' return a RowsIterator as well,
+// whose Err and Close are available by type assertion
+type RowsIterator[T any] struct {
+	ctx   context.Context
+	rows  Rows
+	ready bool
+	done  bool
+	err   error
+}
+
+func (iter *RowsIterator[T]) Next() bool {
+	if iter.done {
+		return false
+	}
+
+	if iter.ready {
+		return true
+	}
+
+	if err := iter.ctx.Err(); err != nil {
+		iter.err = err
+		iter.Close()
+		return false
+	}
+
+	if iter.rows.Next() {
+		iter.ready = true
+		return true
+	}
+
+	iter.err = iter.rows.Err()
+	iter.Close()
+	return false
+}
+
+func (iter *RowsIterator[T]) Value() (v T) {
+	if !iter.Next() {
+		return v
+	}
+
+	iter.ready = false
+	v, err := scanRow[T](iter.rows)
+	if err != nil {
+		iter.err = err
+		iter.Close()
+	}
+
+	return v
+}
+
+// Err returns the first error occurred while iterating
+func (iter *RowsIterator[T]) Err() error {
+	return iter.err
+}
+
+// Close closes the underlying rows, it is safe to call Close multiple times
+func (iter *RowsIterator[T]) Close() error {
+	if iter.done {
+		return nil
+	}
+	iter.done = true
+	iter.ready = false
+	runtime.SetFinalizer(iter, nil)
+	return iter.rows.Close()
+}
+
+// ScanRows scans each row into T and calls f with it, the iteration stops
+// at the first error returned from f, rows would always be closed
+func ScanRows[T any](ctx context.Context, rows Rows, f func(T) error) error {
+	iter := NewRowsIterator[T](ctx, rows)
+	defer iter.Close()
+
+	for iter.Next() {
+		v := iter.Value()
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if err := f(v); err != nil {
+			return err
+		}
+	}
+
+	return iter.Err()
+}
@@ -1,6 +1,8 @@
 package mrpkg
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -50,3 +52,187 @@ func TestMergeNamedArgs(t *testing.T) {
 		t.Errorf("MergeNamedArgs: expect=%v; got=%v", expect, got)
 	}
 }
+
+func TestBatchSize(t *testing.T) {
+	defer func(max int) { MaxBindVars = max }(MaxBindVars)
+	MaxBindVars = 10
+
+	for _, testCase := range []struct {
+		n      int
+		expect int
+	}{
+		{-1, 10},
+		{0, 10},
+		{1, 10},
+		{3, 3},
+		{11, 1},
+	} {
+		if got := BatchSize(testCase.n); got != testCase.expect {
+			t.Errorf("BatchSize(%d): expect=%d; got=%d", testCase.n, testCase.expect, got)
+		}
+	}
+}
+
+type batchRowType struct {
+	Name string
+	Id   int64
+}
+
+func (row *batchRowType) ToArgs() []any {
+	return []any{row.Name, row.Id}
+}
+
+func TestMergeBatchArgs(t *testing.T) {
+	expect := []any{"a", int64(1), "b", int64(2)}
+	got := MergeBatchArgs([]batchRowType{{"a", 1}, {"b", 2}})
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("MergeBatchArgs: expect=%v; got=%v", expect, got)
+	}
+
+	expect = []any{1, 2, 3, 4}
+	got = MergeBatchArgs([][]int{{1, 2}, {3, 4}})
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("MergeBatchArgs: expect=%v; got=%v", expect, got)
+	}
+}
+
+func TestExpandValues(t *testing.T) {
+	got, err := ExpandValues("INSERT INTO user (id, name) values (?, NOW(?)) ON CONFLICT DO NOTHING", 3)
+	if err != nil {
+		t.Fatalf("ExpandValues: %s", err)
+	}
+
+	expect := "INSERT INTO user (id, name) values (?, NOW(?)), (?, NOW(?)), (?, NOW(?)) ON CONFLICT DO NOTHING"
+	if got != expect {
+		t.Errorf("ExpandValues: expect=%q; got=%q", expect, got)
+	}
+
+	for sql, expect := range map[string]string{
+		"INSERT INTO stats (values_count, external_values) VALUES (?, ?)":         "INSERT INTO stats (values_count, external_values) VALUES (?, ?), (?, ?)",
+		"INSERT INTO user (name) /* values (x) */ VALUES (CONCAT('values (', ?))": "INSERT INTO user (name) /* values (x) */ VALUES (CONCAT('values (', ?)), (CONCAT('values (', ?))",
+		"INSERT INTO `values` (id) VALUES (?)":                                    "INSERT INTO `values` (id) VALUES (?), (?)",
+	} {
+		if got, err = ExpandValues(sql, 2); err != nil || got != expect {
+			t.Errorf("ExpandValues(%q): expect=%q; got=%q, err=%v", sql, expect, got, err)
+		}
+	}
+
+	for _, sql := range []string{
+		"INSERT INTO user (id) SELECT id FROM other",
+		"INSERT INTO user (values_count) SELECT ?",
+		"INSERT INTO user (id) VALUES (?",
+		"INSERT INTO user (id) VALUES (?) ON DUPLICATE KEY UPDATE id = ?",
+	} {
+		if _, err = ExpandValues(sql, 2); err == nil {
+			t.Errorf("ExpandValues(%q): expect error, got nil", sql)
+		}
+	}
+}
+
+type fakeResult struct {
+	id, affected int64
+}
+
+func (result fakeResult) LastInsertId() (int64, error) { return result.id, nil }
+
+func (result fakeResult) RowsAffected() (int64, error) { return result.affected, nil }
+
+func TestResults(t *testing.T) {
+	var results Results
+	if _, err := results.LastInsertId(); err == nil {
+		t.Errorf("Results.LastInsertId: expect error with no statement, got nil")
+	}
+
+	results.Statements = append(results.Statements,
+		fakeResult{id: 1, affected: 2},
+		fakeResult{id: 0, affected: 3},
+		fakeResult{id: 7, affected: 1},
+	)
+
+	if id, err := results.LastInsertId(); err != nil || id != 7 {
+		t.Errorf("Results.LastInsertId: expect=7; got=%d, err=%v", id, err)
+	}
+
+	if n, err := results.RowsAffected(); err != nil || n != 6 {
+		t.Errorf("Results.RowsAffected: expect=6; got=%d, err=%v", n, err)
+	}
+}
+
+type fakeRows struct {
+	rows   [][]any
+	closed bool
+}
+
+func (rows *fakeRows) Next() bool { return len(rows.rows) > 0 }
+
+func (rows *fakeRows) Scan(dest ...any) error {
+	row := rows.rows[0]
+	rows.rows = rows.rows[1:]
+	reflect.ValueOf(dest[0]).Elem().Set(reflect.ValueOf(row[0]))
+	return nil
+}
+
+func (rows *fakeRows) StructScan(dest any) error {
+	row := rows.rows[0]
+	rows.rows = rows.rows[1:]
+	rv := reflect.ValueOf(dest).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		rv.Field(i).Set(reflect.ValueOf(row[i]))
+	}
+	return nil
+}
+
+func (rows *fakeRows) Err() error { return nil }
+
+func (rows *fakeRows) Close() error {
+	rows.closed = true
+	return nil
+}
+
+func TestRowsIterator(t *testing.T) {
+	rows := &fakeRows{rows: [][]any{{"a", int64(1)}, {"b", int64(2)}}}
+	got := ToGoSlice[*batchRowType](NewRowsIterator[*batchRowType](context.Background(), rows))
+	expect := []*batchRowType{{"a", 1}, {"b", 2}}
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("RowsIterator: expect=%v; got=%v", expect, got)
+	}
+
+	if !rows.closed {
+		t.Errorf("RowsIterator: rows not closed after exhausted")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows = &fakeRows{rows: [][]any{{1}, {2}}}
+	iter := NewRowsIterator[int](ctx, rows)
+	if !iter.Next() || iter.Value() != 1 {
+		t.Errorf("RowsIterator: expect first value 1")
+	}
+
+	cancel()
+	if iter.Next() {
+		t.Errorf("RowsIterator: expect no more values after ctx canceled")
+	}
+
+	if !errors.Is(iter.Err(), context.Canceled) || !rows.closed {
+		t.Errorf("RowsIterator: expect context.Canceled and closed rows, got err=%v; closed=%v", iter.Err(), rows.closed)
+	}
+}
+
+func TestScanRows(t *testing.T) {
+	var (
+		rows = &fakeRows{rows: [][]any{{1}, {2}, {3}}}
+		stop = errors.New("stop")
+		got  []int
+	)
+
+	err := ScanRows(context.Background(), rows, func(v int) error {
+		if got = append(got, v); len(got) == 2 {
+			return stop
+		}
+		return nil
+	})
+
+	if err != stop || !reflect.DeepEqual(got, []int{1, 2}) || !rows.closed {
+		t.Errorf("ScanRows: err=%v; got=%v; closed=%v", err, got, rows.closed)
+	}
+}
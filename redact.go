@@ -0,0 +1,50 @@
+package mrpkg
+
+// Redacted replaces sensitive args passed to Log of loadc generated code
+// with 'sqlx/log' feature, or kept in Args of QueryError, which are params
+// marked by REDACT(...) feature or args implementing Redactor
+const Redacted = "[REDACTED]"
+
+// Redactor is implemented by arg types holding sensitive values, Redact
+// returns the value to be logged, while the arg itself still reaches driver
+type Redactor interface {
+	Redact() any
+}
+
+// RedactArgs returns args with values implementing Redactor replaced by their
+// Redact results, args should be either []any or map[string]any, args is
+// returned as is if there is nothing to redact
+func RedactArgs(args any) any {
+	switch args := args.(type) {
+	case []any:
+		var dst []any
+		for i, arg := range args {
+			if redactor, ok := arg.(Redactor); ok {
+				if dst == nil {
+					dst = append(make([]any, 0, len(args)), args...)
+				}
+				dst[i] = redactor.Redact()
+			}
+		}
+		if dst != nil {
+			return dst
+		}
+	case map[string]any:
+		var dst map[string]any
+		for name, arg := range args {
+			if redactor, ok := arg.(Redactor); ok {
+				if dst == nil {
+					dst = make(map[string]any, len(args))
+					for k, v := range args {
+						dst[k] = v
+					}
+				}
+				dst[name] = redactor.Redact()
+			}
+		}
+		if dst != nil {
+			return dst
+		}
+	}
+	return args
+}
@@ -0,0 +1,35 @@
+package mrpkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+type password string
+
+func (password) Redact() any { return Redacted }
+
+func TestRedactArgs(t *testing.T) {
+	args := []any{"alice", password("secret")}
+	if got, expect := RedactArgs(args), []any{"alice", Redacted}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("RedactArgs: expect=%v; got=%v", expect, got)
+	}
+
+	if args[1] != password("secret") {
+		t.Errorf("RedactArgs: expect args untouched, got %v", args)
+	}
+
+	named := map[string]any{"name": "alice", "password": password("secret")}
+	if got, expect := RedactArgs(named), map[string]any{"name": "alice", "password": Redacted}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("RedactArgs: expect=%v; got=%v", expect, got)
+	}
+
+	if named["password"] != password("secret") {
+		t.Errorf("RedactArgs: expect named args untouched, got %v", named)
+	}
+
+	plain := []any{"alice", 1}
+	if got := RedactArgs(plain); !reflect.DeepEqual(got, plain) {
+		t.Errorf("RedactArgs: expect=%v; got=%v", plain, got)
+	}
+}
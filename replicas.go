@@ -0,0 +1,81 @@
+package mrpkg
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaCooldown is the period a replica is skipped by Replicas.Next after
+// it is reported with a connection error
+var ReplicaCooldown = 5 * time.Second
+
+// Replicas selects replicas in round-robin order, replicas reported with
+// connection errors are skipped until ReplicaCooldown passes, a nil Replicas
+// has no replica available
+type Replicas[T any] struct {
+	replicas []T
+	next     atomic.Uint64
+	downs    []atomic.Int64
+}
+
+func NewReplicas[T any](replicas ...T) *Replicas[T] {
+	return &Replicas[T]{
+		replicas: replicas,
+		downs:    make([]atomic.Int64, len(replicas)),
+	}
+}
+
+// Next returns the next healthy replica and its index, ok is false if there
+// is no healthy replica
+func (r *Replicas[T]) Next() (index int, replica T, ok bool) {
+	if r == nil || len(r.replicas) == 0 {
+		return -1, replica, false
+	}
+
+	var (
+		n     = uint64(len(r.replicas))
+		start = r.next.Add(1) - 1
+		now   = time.Now().UnixNano()
+	)
+
+	for i := uint64(0); i < n; i++ {
+		index = int((start + i) % n)
+		if r.downs[index].Load() <= now {
+			return index, r.replicas[index], true
+		}
+	}
+
+	return -1, replica, false
+}
+
+// At returns replica at index
+func (r *Replicas[T]) At(index int) T {
+	return r.replicas[index]
+}
+
+// Report reports err returned by replica at index, the replica is marked down
+// for ReplicaCooldown if err is a connection error, negative index is ignored
+func (r *Replicas[T]) Report(index int, err error) {
+	if r == nil || index < 0 || !IsConnError(err) {
+		return
+	}
+	r.downs[index].Store(time.Now().Add(ReplicaCooldown).UnixNano())
+}
+
+// IsConnError reports whether err is caused by a broken connection rather than
+// the query itself, errors of cancelled or timed out ctx are not connection
+// errors, though context.DeadlineExceeded implements net.Error
+func IsConnError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
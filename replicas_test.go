@@ -0,0 +1,52 @@
+package mrpkg
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestReplicas(t *testing.T) {
+	var nilReplicas *Replicas[string]
+	if _, _, ok := nilReplicas.Next(); ok {
+		t.Fatalf("Replicas.Next: expect no replica from nil Replicas")
+	}
+
+	replicas := NewReplicas("a", "b", "c")
+	for _, expect := range []string{"a", "b", "c", "a"} {
+		if _, got, ok := replicas.Next(); !ok || got != expect {
+			t.Fatalf("Replicas.Next: expect=%s; got=%s", expect, got)
+		}
+	}
+
+	replicas.Report(1, errors.New("syntax error"))
+	replicas.Report(2, fmt.Errorf("query: %w", driver.ErrBadConn))
+	for _, expect := range []string{"b", "a", "a"} {
+		if _, got, ok := replicas.Next(); !ok || got != expect {
+			t.Fatalf("Replicas.Next: expect=%s; got=%s", expect, got)
+		}
+	}
+
+	replicas.Report(0, driver.ErrBadConn)
+	replicas.Report(1, driver.ErrBadConn)
+	if index, _, ok := replicas.Next(); ok || index != -1 {
+		t.Fatalf("Replicas.Next: expect no healthy replica, got %d", index)
+	}
+}
+
+func TestReplicasDeadline(t *testing.T) {
+	replicas := NewReplicas("a", "b")
+	replicas.Report(0, fmt.Errorf("query: %w", context.DeadlineExceeded))
+	replicas.Report(1, context.Canceled)
+	for _, expect := range []string{"a", "b", "a"} {
+		if _, got, ok := replicas.Next(); !ok || got != expect {
+			t.Fatalf("Replicas.Next: expect=%s; got=%s", expect, got)
+		}
+	}
+
+	if IsConnError(context.DeadlineExceeded) {
+		t.Errorf("IsConnError: expect context.DeadlineExceeded not to be connection error")
+	}
+}
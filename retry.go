@@ -0,0 +1,23 @@
+package mrpkg
+
+import "time"
+
+// DefaultRetryMax and DefaultRetryBackoff are the retry policy used by
+// generated code when no policy is provided, DefaultRetryMax is the number of
+// retries after the first attempt
+var (
+	DefaultRetryMax     = 3
+	DefaultRetryBackoff = ExponentialBackoff(10*time.Millisecond, time.Second)
+)
+
+// ExponentialBackoff returns a backoff function which doubles the delay from
+// base for each attempt (starting from 0), the delay never exceeds max
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 0; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		return Min(delay, max)
+	}
+}
@@ -0,0 +1,25 @@
+package mrpkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for attempt, expect := range []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		50 * time.Millisecond,
+		50 * time.Millisecond,
+	} {
+		if got := backoff(attempt); got != expect {
+			t.Errorf("ExponentialBackoff(%d): expect=%s; got=%s", attempt, expect, got)
+		}
+	}
+
+	if got := backoff(1000); got != 50*time.Millisecond {
+		t.Errorf("ExponentialBackoff(1000): expect=%s; got=%s", 50*time.Millisecond, got)
+	}
+}
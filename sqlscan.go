@@ -0,0 +1,192 @@
+package mrpkg
+
+import "strings"
+
+// BackslashEscapes tells whether '\' escapes the next character in quoted
+// strings scanned by SplitSql and CountBindVars, which is true for MySQL (the
+// default), set it to false for databases following standard SQL, such as
+// Postgres with standard_conforming_strings on, where 'C:\' is a whole string
+var BackslashEscapes = true
+
+// SplitSql splits sql into statements by ';', which are trimmed and
+// non-empty, a ';' inside string literals, quoted identifiers, comments or
+// dollar-quoted strings does not terminate a statement
+func SplitSql(sql string) []string {
+	var (
+		stmts []string
+		start int
+	)
+
+	appendStmt := func(stmt string) {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	scanSql(sql, func(i int) {
+		if sql[i] == ';' {
+			appendStmt(sql[start:i])
+			start = i + 1
+		}
+	})
+
+	appendStmt(sql[start:])
+	return stmts
+}
+
+// CountBindVars returns the number of '?' bind vars in sql, which are not
+// inside string literals, quoted identifiers, comments or dollar-quoted
+// strings, and are not part of operators '?|', '?&' or '??' (an escaped '?')
+func CountBindVars(sql string) int {
+	var (
+		n    int
+		skip = -1
+	)
+
+	scanSql(sql, func(i int) {
+		if sql[i] != '?' || i == skip {
+			return
+		}
+
+		if i+1 < len(sql) {
+			switch sql[i+1] {
+			case '|', '&', '?':
+				skip = i + 1
+				return
+			}
+		}
+
+		n++
+	})
+
+	return n
+}
+
+// Kinds of segments reported by ScanSql
+const (
+	SqlSegmentCode = iota
+	SqlSegmentString
+	SqlSegmentIdent
+	SqlSegmentComment
+)
+
+// ScanSql calls f with kind and range [start, end) of each segment in sql,
+// code is reported byte by byte, while string literals (including
+// dollar-quoted strings), quoted identifiers and comments are reported as a
+// whole, unterminated ones run to the end of sql
+func ScanSql(sql string, f func(kind int, start int, end int)) {
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(sql, i)
+			kind := SqlSegmentIdent
+			if c == '\'' {
+				kind = SqlSegmentString
+			}
+			f(kind, i, clamp(end+1, len(sql)))
+			i = end
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := len(sql)
+			if n := strings.IndexByte(sql[i:], '\n'); n != -1 {
+				end = i + n
+			}
+			f(SqlSegmentComment, i, end)
+			i = end - 1
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := len(sql)
+			if n := strings.Index(sql[i+2:], "*/"); n != -1 {
+				end = i + 2 + n + 2
+			}
+			f(SqlSegmentComment, i, end)
+			i = end - 1
+		case c == '$' && (i == 0 || !isIdentByte(sql[i-1])):
+			tag := dollarTag(sql[i:])
+			if tag == "" {
+				f(SqlSegmentCode, i, i+1)
+				continue
+			}
+			end := len(sql)
+			if n := strings.Index(sql[i+len(tag):], tag); n != -1 {
+				end = i + len(tag) + n + len(tag)
+			}
+			f(SqlSegmentString, i, end)
+			i = end - 1
+		default:
+			f(SqlSegmentCode, i, i+1)
+		}
+	}
+}
+
+// scanSql calls f with index of each byte in sql which is not part of string
+// literals, quoted identifiers, comments or dollar-quoted strings
+func scanSql(sql string, f func(i int)) {
+	ScanSql(sql, func(kind int, start int, _ int) {
+		if kind == SqlSegmentCode {
+			f(start)
+		}
+	})
+}
+
+func clamp(i, max int) int {
+	if i > max {
+		return max
+	}
+	return i
+}
+
+// skipQuoted returns index of the closing quote of quoted string starting at
+// sql[start], a doubled quote is treated as an escaped one, and so is a quote
+// following '\' with BackslashEscapes, except in backtick-quoted identifiers
+func skipQuoted(sql string, start int) int {
+	quote := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if BackslashEscapes && quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(sql)
+}
+
+// dollarTag returns the opening tag of dollar-quoted string at the beginning
+// of sql, such as '$$' or '$body$', or empty string if sql does not start
+// with one, positional bind vars like '$1' are not tags
+func dollarTag(sql string) string {
+	for i := 1; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '$':
+			return sql[:i+1]
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= 0x80:
+		case '0' <= c && c <= '9' && i > 1:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// isKeywordAt reports whether keyword (case-insensitive) starts at sql[i] as
+// a whole word, rather than part of identifiers like 'values_count'
+func isKeywordAt(sql string, i int, keyword string) bool {
+	end := i + len(keyword)
+	return end <= len(sql) &&
+		strings.EqualFold(sql[i:end], keyword) &&
+		(i == 0 || !isIdentByte(sql[i-1])) &&
+		(end == len(sql) || !isIdentByte(sql[end]))
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' ||
+		'a' <= c && c <= 'z' ||
+		'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9' ||
+		c >= 0x80
+}
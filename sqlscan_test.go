@@ -0,0 +1,95 @@
+package mrpkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSql(t *testing.T) {
+	sql := "" +
+		"INSERT INTO t (a, b) VALUES ('x;y', \"c;d\"); -- comment; with ?\n" +
+		"UPDATE t SET b = `e;f` /* block; comment */ WHERE a = ?;\n" +
+		"CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;\n" +
+		"SELECT 'it''s;' FROM t WHERE c = 'a\\';b';;"
+
+	expect := []string{
+		"INSERT INTO t (a, b) VALUES ('x;y', \"c;d\")",
+		"-- comment; with ?\nUPDATE t SET b = `e;f` /* block; comment */ WHERE a = ?",
+		"CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql",
+		"SELECT 'it''s;' FROM t WHERE c = 'a\\';b'",
+	}
+
+	if got := SplitSql(sql); !reflect.DeepEqual(got, expect) {
+		t.Errorf("SplitSql: expect=%q; got=%q", expect, got)
+	}
+
+	if got := SplitSql(" ; \n "); len(got) != 0 {
+		t.Errorf("SplitSql: expect no statement, got %q", got)
+	}
+}
+
+func TestCountBindVars(t *testing.T) {
+	for sql, expect := range map[string]int{
+		"SELECT * FROM t WHERE a = ? AND b = ?":                         2,
+		"SELECT '?' , \"?\", `?` FROM t WHERE a = ? -- ?\n":             1,
+		"SELECT * FROM t /* ? */ WHERE tags ?| ? AND tags ?& ?":         2,
+		"SELECT * FROM t WHERE data ?? 'key' AND a = ?":                 1,
+		"SELECT $$ ? $$, $tag$ ? $tag$, $1, a$b FROM t WHERE a = ?":     1,
+		"INSERT INTO t (a) VALUES ('it''s ?'), (?)":                     1,
+		"SELECT * FROM t WHERE a = 'unterminated ?":                     0,
+		"SELECT * FROM t WHERE a = ? AND b = ?::int AND c = ANY(?)":     3,
+		"SELECT * FROM t WHERE a = ? /* unterminated ? comment":         1,
+		"SELECT $body$ unterminated ? dollar quoted string WHERE a = ?": 0,
+	} {
+		if got := CountBindVars(sql); got != expect {
+			t.Errorf("CountBindVars(%q): expect=%d; got=%d", sql, expect, got)
+		}
+	}
+}
+
+func TestStandardConformingStrings(t *testing.T) {
+	BackslashEscapes = false
+	defer func() { BackslashEscapes = true }()
+
+	sql := "UPDATE t SET path = 'C:\\' WHERE a = ?; SELECT ? FROM t"
+	expect := []string{
+		"UPDATE t SET path = 'C:\\' WHERE a = ?",
+		"SELECT ? FROM t",
+	}
+
+	if got := SplitSql(sql); !reflect.DeepEqual(got, expect) {
+		t.Errorf("SplitSql: expect=%q; got=%q", expect, got)
+	}
+
+	if got := CountBindVars(sql); got != 2 {
+		t.Errorf("CountBindVars(%q): expect=2; got=%d", sql, got)
+	}
+}
+
+func TestScanSql(t *testing.T) {
+	type segment struct {
+		kind int
+		text string
+	}
+
+	sql := "a'b'\"c\"-- d\n/* e */$f$g$f$h"
+	var got []segment
+	ScanSql(sql, func(kind int, start int, end int) {
+		got = append(got, segment{kind: kind, text: sql[start:end]})
+	})
+
+	expect := []segment{
+		{SqlSegmentCode, "a"},
+		{SqlSegmentString, "'b'"},
+		{SqlSegmentIdent, "\"c\""},
+		{SqlSegmentComment, "-- d"},
+		{SqlSegmentCode, "\n"},
+		{SqlSegmentComment, "/* e */"},
+		{SqlSegmentString, "$f$g$f$"},
+		{SqlSegmentCode, "h"},
+	}
+
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("ScanSql: expect=%q; got=%q", expect, got)
+	}
+}
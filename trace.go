@@ -0,0 +1,38 @@
+package mrpkg
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Tracer starts a span around statement (or transaction) named by caller and
+// query, the returned func ends the span with error of the statement, Tracer
+// is implemented by Core of code generated with 'sqlx/trace' feature
+type Tracer interface {
+	Start(ctx context.Context, caller string, query string) (context.Context, func(error))
+}
+
+// RowsAffectedTracer is optionally implemented by Tracer to record rows
+// affected by statement in span of ctx, which is returned by Tracer.Start
+type RowsAffectedTracer interface {
+	TraceRowsAffected(ctx context.Context, rows int64)
+}
+
+// StartTrace starts a span with tracer if it implements Tracer, or returns
+// ctx as is with a no-op end func otherwise
+func StartTrace(ctx context.Context, tracer any, caller string, query string) (context.Context, func(error)) {
+	if tracer, ok := tracer.(Tracer); ok {
+		return tracer.Start(ctx, caller, query)
+	}
+	return ctx, func(error) {}
+}
+
+// TraceResult records rows affected of result with tracer if it implements
+// RowsAffectedTracer, drivers which do not support RowsAffected are ignored
+func TraceResult(ctx context.Context, tracer any, result sql.Result) {
+	if tracer, ok := tracer.(RowsAffectedTracer); ok && result != nil {
+		if rows, err := result.RowsAffected(); err == nil {
+			tracer.TraceRowsAffected(ctx, rows)
+		}
+	}
+}
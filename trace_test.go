@@ -0,0 +1,57 @@
+package mrpkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type traceKey struct{}
+
+type fakeTracer struct {
+	spans []string
+	errs  []error
+	rows  []int64
+}
+
+func (tracer *fakeTracer) Start(ctx context.Context, caller string, query string) (context.Context, func(error)) {
+	tracer.spans = append(tracer.spans, caller+": "+query)
+	return context.WithValue(ctx, traceKey{}, caller), func(err error) {
+		tracer.errs = append(tracer.errs, err)
+	}
+}
+
+func (tracer *fakeTracer) TraceRowsAffected(ctx context.Context, rows int64) {
+	if ctx.Value(traceKey{}) == nil {
+		panic("TraceRowsAffected: expects ctx returned by Start")
+	}
+	tracer.rows = append(tracer.rows, rows)
+}
+
+func TestStartTrace(t *testing.T) {
+	ctx := context.Background()
+	if got, end := StartTrace(ctx, struct{}{}, "Get", "SELECT 1"); got != ctx {
+		t.Fatalf("StartTrace: expect ctx returned as is without Tracer")
+	} else {
+		end(nil)
+	}
+
+	tracer := new(fakeTracer)
+	errExec := errors.New("exec")
+	spanCtx, end := StartTrace(ctx, tracer, "Update", "UPDATE t SET a = ?")
+	TraceResult(spanCtx, tracer, fakeResult{affected: 3})
+	TraceResult(spanCtx, tracer, nil)
+	end(errExec)
+
+	if len(tracer.spans) != 1 || tracer.spans[0] != "Update: UPDATE t SET a = ?" {
+		t.Errorf("StartTrace: unexpected spans %q", tracer.spans)
+	}
+
+	if len(tracer.errs) != 1 || tracer.errs[0] != errExec {
+		t.Errorf("StartTrace: unexpected errors %v", tracer.errs)
+	}
+
+	if len(tracer.rows) != 1 || tracer.rows[0] != 3 {
+		t.Errorf("TraceResult: unexpected rows affected %v", tracer.rows)
+	}
+}
@@ -0,0 +1,36 @@
+package mrpkg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// Execer executes sql without returning rows, which is implemented by
+// *sql.Tx and *sqlx.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Savepoint runs f as a nested transaction of tx, the savepoint named name is
+// rolled back to if f returns error, and released otherwise, so that changes
+// made by f could be discarded without aborting the whole transaction
+func Savepoint(ctx context.Context, tx Execer, name string, f func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("error creating savepoint %s: %w", strconv.Quote(name), err)
+	}
+
+	if err := f(); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rollbackErr != nil {
+			return fmt.Errorf("error rolling back to savepoint %s: %v: %w", strconv.Quote(name), rollbackErr, err)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("error releasing savepoint %s: %w", strconv.Quote(name), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package mrpkg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type fakeExecer struct {
+	queries []string
+}
+
+func (execer *fakeExecer) ExecContext(_ context.Context, query string, _ ...any) (sql.Result, error) {
+	execer.queries = append(execer.queries, query)
+	return nil, nil
+}
+
+func TestSavepoint(t *testing.T) {
+	execer := new(fakeExecer)
+	if err := Savepoint(context.Background(), execer, "sp1", func() error { return nil }); err != nil {
+		t.Fatalf("Savepoint: %s", err)
+	}
+
+	errInner := errors.New("inner")
+	if err := Savepoint(context.Background(), execer, "sp2", func() error { return errInner }); !errors.Is(err, errInner) {
+		t.Fatalf("Savepoint: expect error %v, got %v", errInner, err)
+	}
+
+	expect := []string{
+		"SAVEPOINT sp1",
+		"RELEASE SAVEPOINT sp1",
+		"SAVEPOINT sp2",
+		"ROLLBACK TO SAVEPOINT sp2",
+	}
+
+	if !reflect.DeepEqual(execer.queries, expect) {
+		t.Errorf("Savepoint: expect=%q; got=%q", expect, execer.queries)
+	}
+}